@@ -1,23 +1,27 @@
 package main
 
 import (
-	"flag"
+	"os"
 
 	. "logsviewer/pkg/backend"
+	"logsviewer/pkg/backend/config"
 	"logsviewer/pkg/backend/log"
 )
 
 func main() {
 	log.Log.Println("Starting logsviewer")
 
-	publicDir := flag.String("public-dir", "./frontend/build/", "directory containing static web assets.")
-	insightsBinaryPath := flag.String("insights-binary-path", "", "path to the insights-client binary.")
-	flag.Parse()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Log.Fatalln(err)
+	}
 
-	log.Log.Printf("public-dir: %s", *publicDir)
-	log.Log.Printf("insights-binary-path: %s", *insightsBinaryPath)
+	log.Log.Printf("public-dir: %s", cfg.PublicDir)
+	log.Log.Printf("data-dir: %s", cfg.DataDir)
+	log.Log.Printf("insights-binary-path: %s", cfg.Insights.BinaryPath)
+	log.Log.Printf("db-driver: %s", cfg.Database.Driver)
 
-	if spawnErr := Spawn(*publicDir, *insightsBinaryPath); spawnErr != nil {
+	if spawnErr := Spawn(cfg); spawnErr != nil {
 		panic(spawnErr)
 	}
 }
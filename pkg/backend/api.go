@@ -0,0 +1,337 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"logsviewer/pkg/backend/auth"
+	"logsviewer/pkg/backend/db"
+	"logsviewer/pkg/backend/log"
+)
+
+const apiPrefix = "/api/v1"
+
+// apiError is the single error body returned by every API endpoint
+type apiError struct {
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func newAPIError(code int, format string, args ...interface{}) *apiError {
+	return &apiError{Code: code, Reason: strings.ReplaceAll(http.StatusText(code), " ", ""), Message: fmt.Sprintf(format, args...)}
+}
+
+func badRequest(format string, args ...interface{}) *apiError {
+	return newAPIError(http.StatusBadRequest, format, args...)
+}
+
+func notFound(format string, args ...interface{}) *apiError {
+	return newAPIError(http.StatusNotFound, format, args...)
+}
+
+func forbidden(format string, args ...interface{}) *apiError {
+	return newAPIError(http.StatusForbidden, format, args...)
+}
+
+func conflict(format string, args ...interface{}) *apiError {
+	return newAPIError(http.StatusConflict, format, args...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		log.Log.Println("failed to encode response: ", err)
+	}
+}
+
+// writeError maps err to its status code, lookups of missing rows become 404,
+// expired list cursors and lookups mixing clusters 400
+func writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(*apiError)
+	if !ok {
+		if db.IsNotFound(err) {
+			apiErr = notFound("the requested object was not found")
+		} else if errors.Is(err, db.ErrCursorExpired) || errors.Is(err, db.ErrMixedClusters) {
+			apiErr = badRequest("%s", err.Error())
+		} else {
+			apiErr = newAPIError(http.StatusInternalServerError, "%s", err.Error())
+		}
+	}
+	writeJSON(w, apiErr.Code, map[string]interface{}{"error": apiErr})
+}
+
+type paramKind string
+
+const (
+	stringParam    paramKind = "string"
+	integerParam   paramKind = "integer"
+	durationParam  paramKind = "duration"
+	timestampParam paramKind = "date-time"
+)
+
+// apiParam describes a path or query parameter. The router validates
+// parameters against their description before calling the handler.
+type apiParam struct {
+	name        string
+	in          string
+	kind        paramKind
+	required    bool
+	enum        []string
+	description string
+}
+
+func pathParam(name string, description string) apiParam {
+	return apiParam{name: name, in: "path", kind: stringParam, required: true, description: description}
+}
+
+func queryParam(name string, description string) apiParam {
+	return apiParam{name: name, in: "query", kind: stringParam, description: description}
+}
+
+func (p apiParam) withRequired() apiParam {
+	p.required = true
+	return p
+}
+
+func (p apiParam) withEnum(values ...string) apiParam {
+	p.enum = values
+	return p
+}
+
+func (p apiParam) withKind(kind paramKind) apiParam {
+	p.kind = kind
+	return p
+}
+
+// apiAlias keeps a legacy path working. Its query parameters are renamed
+// to the route's parameters, and rewrite can adjust the values further.
+type apiAlias struct {
+	path    string
+	rename  map[string]string
+	rewrite func(values map[string]string)
+}
+
+type apiRoute struct {
+	method  string
+	path    string
+	summary string
+	role    auth.Role // the least role allowed to call the route
+	params  []apiParam
+	// errors lists the status codes, besides 500, the route may answer with
+	errors  []int
+	aliases []apiAlias
+	body    map[string]interface{} // JSON schema of the request body, if any
+	handle  func(req *apiRequest) (interface{}, error)
+}
+
+// apiRequest carries the validated parameters of a request
+type apiRequest struct {
+	*http.Request
+	values map[string]string
+}
+
+func (r *apiRequest) str(name string) string {
+	return r.values[name]
+}
+
+// integer returns a validated integer parameter, or def when it's missing
+func (r *apiRequest) integer(name string, def int) int {
+	value, exists := r.values[name]
+	if !exists {
+		return def
+	}
+	i, _ := strconv.Atoi(value)
+	return i
+}
+
+// decode reads the JSON request body into v, rejecting unknown fields
+func (r *apiRequest) decode(v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid request body: %s", err.Error())
+	}
+	return nil
+}
+
+// duration returns a validated duration parameter, or def when it's missing
+func (r *apiRequest) duration(name string, def time.Duration) time.Duration {
+	value, exists := r.values[name]
+	if !exists {
+		return def
+	}
+	d, _ := time.ParseDuration(value)
+	return d
+}
+
+// timestamp returns a validated RFC 3339 parameter, or a zero time when it's missing
+func (r *apiRequest) timestamp(name string) time.Time {
+	value, exists := r.values[name]
+	if !exists {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, value)
+	return t
+}
+
+func (route *apiRoute) validate(r *http.Request, values map[string]string) error {
+	for _, p := range route.params {
+		value, exists := values[p.name]
+		if !exists || value == "" {
+			if p.required {
+				return badRequest("missing required %s parameter %q", p.in, p.name)
+			}
+			delete(values, p.name)
+			continue
+		}
+		switch p.kind {
+		case integerParam:
+			if _, err := strconv.Atoi(value); err != nil {
+				return badRequest("parameter %q must be an integer, got %q", p.name, value)
+			}
+		case durationParam:
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return badRequest("parameter %q must be a positive duration such as 90s or 5m, got %q", p.name, value)
+			}
+		case timestampParam:
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return badRequest("parameter %q must be an RFC 3339 timestamp, got %q", p.name, value)
+			}
+		}
+		if len(p.enum) > 0 && !contains(p.enum, value) {
+			if p.in == "path" {
+				return notFound("no API endpoint at %s", r.URL.Path)
+			}
+			return badRequest("parameter %q must be one of %s, got %q", p.name, strings.Join(p.enum, ", "), value)
+		}
+	}
+	return nil
+}
+
+func (route *apiRoute) serve(w http.ResponseWriter, r *http.Request, values map[string]string) {
+	if r.Method != route.method && !(route.method == http.MethodGet && r.Method == http.MethodHead) {
+		w.Header().Set("Allow", route.method)
+		writeError(w, newAPIError(http.StatusMethodNotAllowed, "method %s is not allowed on %s", r.Method, r.URL.Path))
+		return
+	}
+	if identity, ok := auth.FromContext(r.Context()); !ok || identity.Role < route.role {
+		writeError(w, forbidden("the %s role is required to %s %s", route.role, r.Method, r.URL.Path))
+		return
+	}
+	if err := route.validate(r, values); err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := route.handle(&apiRequest{Request: r, values: values})
+	if err != nil {
+		log.Log.Println(r.Method, r.URL.Path, "failed: ", err)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// matchPath matches a path against a pattern such as /api/v1/pods/{uid}
+func matchPath(pattern string, path string) (map[string]string, bool) {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return nil, false
+	}
+	vars := map[string]string{}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			vars[strings.Trim(part, "{}")] = pathParts[i]
+		} else if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+func queryValues(r *http.Request) map[string]string {
+	values := map[string]string{}
+	for k, v := range r.URL.Query() {
+		values[k] = v[0]
+	}
+	return values
+}
+
+type apiRouter struct {
+	routes []apiRoute
+}
+
+func (a *apiRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var pathMatched *apiRoute
+	for i := range a.routes {
+		route := &a.routes[i]
+		vars, ok := matchPath(route.path, r.URL.Path)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method && !(route.method == http.MethodGet && r.Method == http.MethodHead) {
+			pathMatched = route
+			continue
+		}
+		values := queryValues(r)
+		for k, v := range vars {
+			values[k] = v
+		}
+		route.serve(w, r, values)
+		return
+	}
+	if pathMatched != nil {
+		pathMatched.serve(w, r, nil)
+		return
+	}
+	writeError(w, notFound("no API endpoint at %s", r.URL.Path))
+}
+
+// register serves the API routes and their legacy aliases on mux
+func (a *apiRouter) register(mux *http.ServeMux) {
+	mux.Handle(apiPrefix+"/", a)
+	for i := range a.routes {
+		route := &a.routes[i]
+		for _, alias := range route.aliases {
+			alias := alias
+			mux.HandleFunc(alias.path, func(w http.ResponseWriter, r *http.Request) {
+				values := queryValues(r)
+				for from, to := range alias.rename {
+					if value, exists := values[from]; exists {
+						values[to] = value
+						delete(values, from)
+					}
+				}
+				if alias.rewrite != nil {
+					alias.rewrite(values)
+				}
+				route.serve(w, r, values)
+			})
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
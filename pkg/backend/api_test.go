@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"logsviewer/pkg/backend/auth"
+	"logsviewer/pkg/backend/config"
+)
+
+func TestImportRequiresUploader(t *testing.T) {
+	authenticator, err := auth.New(config.AuthConfig{Mode: config.AuthModeToken, ViewerToken: "viewer-token", UploaderToken: "uploader-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the routes are rejected or fail on the missing file before reaching the store
+	c := &app{config: &config.Config{}}
+	mux := http.NewServeMux()
+	(&apiRouter{routes: c.apiRoutes()}).register(mux)
+	handler := authenticate(authenticator, mux)
+
+	for _, tt := range []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "viewer", path: apiPrefix + "/imports", token: "viewer-token", status: http.StatusForbidden},
+		{name: "viewer on the alias", path: "/uploadLogs", token: "viewer-token", status: http.StatusForbidden},
+		{name: "uploader", path: apiPrefix + "/imports", token: "uploader-token", status: http.StatusBadRequest},
+		{name: "uploader on the alias", path: "/uploadLogs", token: "uploader-token", status: http.StatusBadRequest},
+		{name: "wrong token", path: apiPrefix + "/imports", token: "other-token", status: http.StatusUnauthorized},
+		{name: "anonymous", path: "/uploadLogs", status: http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("POST %s returned %d %s, want %d", tt.path, w.Code, w.Body, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"logsviewer/pkg/backend/config"
+)
+
+// Role is what an identity is allowed to do, an uploader can also view
+type Role int
+
+const (
+	Viewer Role = iota
+	Uploader
+)
+
+func (r Role) String() string {
+	if r == Uploader {
+		return "uploader"
+	}
+	return "viewer"
+}
+
+type Identity struct {
+	User string
+	Role Role
+}
+
+var (
+	// ErrNoCredentials is returned when the request carries no credentials at all
+	ErrNoCredentials = errors.New("authentication is required")
+	// ErrInvalidCredentials is returned when the credentials don't match any user
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator resolves the identity behind a request
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+	// Challenge is the WWW-Authenticate header sent along a 401, if any
+	Challenge() string
+}
+
+// New returns the authenticator of the configured mode
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	uploaders := newUploaders(cfg)
+	switch cfg.Mode {
+	case config.AuthModeNone, "":
+		return anonymous{}, nil
+	case config.AuthModeToken:
+		return &tokenAuthenticator{viewerToken: cfg.ViewerToken, uploaderToken: cfg.UploaderToken}, nil
+	case config.AuthModeHtpasswd:
+		users, err := loadHtpasswd(cfg.HtpasswdFile)
+		if err != nil {
+			return nil, err
+		}
+		return &htpasswdAuthenticator{users: users, uploaders: uploaders}, nil
+	case config.AuthModeProxy:
+		return &proxyAuthenticator{
+			userHeader:   cfg.ProxyUserHeader,
+			groupsHeader: cfg.ProxyGroupsHeader,
+			uploaders:    uploaders,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported auth mode %q", cfg.Mode)
+}
+
+type identityKey struct{}
+
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
+
+// anonymous keeps the historical behavior, everyone may view and upload
+type anonymous struct{}
+
+func (anonymous) Authenticate(r *http.Request) (*Identity, error) {
+	return &Identity{User: "anonymous", Role: Uploader}, nil
+}
+
+func (anonymous) Challenge() string {
+	return ""
+}
+
+// tokenAuthenticator accepts static tokens, either as a bearer token or as
+// the basic auth password so that browsers can log in to the UI as well.
+type tokenAuthenticator struct {
+	viewerToken   string
+	uploaderToken string
+}
+
+func (t *tokenAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token, user := "", "token"
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	} else if username, password, ok := r.BasicAuth(); ok {
+		token = password
+		if username != "" {
+			user = username
+		}
+	}
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	if tokenEquals(token, t.uploaderToken) {
+		return &Identity{User: user, Role: Uploader}, nil
+	}
+	if tokenEquals(token, t.viewerToken) {
+		return &Identity{User: user, Role: Viewer}, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
+func (t *tokenAuthenticator) Challenge() string {
+	return `Basic realm="logsviewer", charset="UTF-8"`
+}
+
+func tokenEquals(given string, expected string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+type htpasswdAuthenticator struct {
+	users     map[string]string
+	uploaders *uploaders
+}
+
+func (h *htpasswdAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	hash, exists := h.users[username]
+	if !exists || !checkPassword(hash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{User: username, Role: h.uploaders.role(username, nil)}, nil
+}
+
+func (h *htpasswdAuthenticator) Challenge() string {
+	return `Basic realm="logsviewer", charset="UTF-8"`
+}
+
+// proxyAuthenticator trusts the user set by the OpenShift oauth-proxy in
+// front of logsviewer. It's only safe when the backend can't be reached
+// without going through the proxy.
+type proxyAuthenticator struct {
+	userHeader   string
+	groupsHeader string
+	uploaders    *uploaders
+}
+
+func (p *proxyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	user := r.Header.Get(p.userHeader)
+	if user == "" {
+		return nil, ErrNoCredentials
+	}
+	var groups []string
+	if p.groupsHeader != "" {
+		for _, value := range r.Header.Values(p.groupsHeader) {
+			groups = append(groups, config.SplitList(value)...)
+		}
+	}
+	return &Identity{User: user, Role: p.uploaders.role(user, groups)}, nil
+}
+
+func (p *proxyAuthenticator) Challenge() string {
+	return ""
+}
+
+// uploaders grants the uploader role to the listed users and groups.
+// When neither is listed, every authenticated user is an uploader.
+type uploaders struct {
+	users  []string
+	groups []string
+}
+
+func newUploaders(cfg config.AuthConfig) *uploaders {
+	return &uploaders{users: config.SplitList(cfg.UploaderUsers), groups: config.SplitList(cfg.UploaderGroups)}
+}
+
+func (u *uploaders) role(user string, groups []string) Role {
+	if len(u.users) == 0 && len(u.groups) == 0 {
+		return Uploader
+	}
+	for _, uploader := range u.users {
+		if uploader == user {
+			return Uploader
+		}
+	}
+	for _, group := range groups {
+		for _, uploaderGroup := range u.groups {
+			if group == uploaderGroup {
+				return Uploader
+			}
+		}
+	}
+	return Viewer
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"logsviewer/pkg/backend/config"
+)
+
+// authCase is a request carrying headers and the identity, or the error,
+// it is authenticated as
+type authCase struct {
+	name    string
+	headers map[string][]string
+	user    string
+	role    Role
+	err     error
+}
+
+func basicAuth(user string, password string) map[string][]string {
+	return map[string][]string{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))}}
+}
+
+func bearer(token string) map[string][]string {
+	return map[string][]string{"Authorization": {"Bearer " + token}}
+}
+
+func runAuthCases(t *testing.T, cfg config.AuthConfig, tests []authCase) {
+	t.Helper()
+	authenticator, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/pods", nil)
+			for header, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(header, value)
+				}
+			}
+			identity, err := authenticator.Authenticate(r)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("authenticated as %v, %v, want %v", identity, err, tt.err)
+				}
+				return
+			}
+			if err != nil || identity.User != tt.user || identity.Role != tt.role {
+				t.Errorf("authenticated as %v, %v, want %s as %s", identity, err, tt.user, tt.role)
+			}
+		})
+	}
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	runAuthCases(t, config.AuthConfig{Mode: config.AuthModeToken, ViewerToken: "viewer-token", UploaderToken: "uploader-token"}, []authCase{
+		{name: "bearer viewer", headers: bearer("viewer-token"), user: "token", role: Viewer},
+		{name: "bearer uploader", headers: bearer("uploader-token"), user: "token", role: Uploader},
+		// browsers log in with the token as the password
+		{name: "basic viewer", headers: basicAuth("alice", "viewer-token"), user: "alice", role: Viewer},
+		{name: "basic uploader", headers: basicAuth("alice", "uploader-token"), user: "alice", role: Uploader},
+		{name: "basic without user", headers: basicAuth("", "viewer-token"), user: "token", role: Viewer},
+		{name: "bearer wrong token", headers: bearer("uploader-token-2"), err: ErrInvalidCredentials},
+		{name: "basic wrong token", headers: basicAuth("alice", "viewer"), err: ErrInvalidCredentials},
+		{name: "token as the user", headers: basicAuth("uploader-token", ""), err: ErrNoCredentials},
+		{name: "empty bearer", headers: bearer(""), err: ErrNoCredentials},
+		{name: "no credentials", err: ErrNoCredentials},
+	})
+}
+
+func TestTokenAuthenticatorWithoutViewerToken(t *testing.T) {
+	// an unset token never matches
+	runAuthCases(t, config.AuthConfig{Mode: config.AuthModeToken, UploaderToken: "uploader-token"}, []authCase{
+		{name: "bearer uploader", headers: bearer("uploader-token"), user: "token", role: Uploader},
+		{name: "basic empty token", headers: basicAuth("alice", ""), err: ErrNoCredentials},
+		{name: "bearer other token", headers: bearer("viewer-token"), err: ErrInvalidCredentials},
+	})
+}
+
+// writeHtpasswd writes an htpasswd file and returns its path
+func writeHtpasswd(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHtpasswdAuthenticator(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("alice-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte("bob-password"))
+	path := writeHtpasswd(t, "# users\n"+
+		"alice:"+string(bcryptHash)+"\n"+
+		"\n"+
+		"bob:{SHA}"+base64.StdEncoding.EncodeToString(sum[:])+"\n")
+
+	runAuthCases(t, config.AuthConfig{Mode: config.AuthModeHtpasswd, HtpasswdFile: path, UploaderUsers: "alice"}, []authCase{
+		{name: "bcrypt uploader", headers: basicAuth("alice", "alice-password"), user: "alice", role: Uploader},
+		{name: "sha viewer", headers: basicAuth("bob", "bob-password"), user: "bob", role: Viewer},
+		{name: "bcrypt wrong password", headers: basicAuth("alice", "bob-password"), err: ErrInvalidCredentials},
+		{name: "sha wrong password", headers: basicAuth("bob", "alice-password"), err: ErrInvalidCredentials},
+		{name: "unknown user", headers: basicAuth("carol", "alice-password"), err: ErrInvalidCredentials},
+		{name: "bearer token", headers: bearer("alice-password"), err: ErrNoCredentials},
+		{name: "no credentials", err: ErrNoCredentials},
+	})
+	// every user uploads when no uploader is listed
+	runAuthCases(t, config.AuthConfig{Mode: config.AuthModeHtpasswd, HtpasswdFile: path}, []authCase{
+		{name: "sha uploader", headers: basicAuth("bob", "bob-password"), user: "bob", role: Uploader},
+	})
+}
+
+func TestLoadHtpasswdRejects(t *testing.T) {
+	for name, content := range map[string]string{
+		"md5":       "alice:$apr1$salt$hash\n",
+		"crypt":     "alice:rl2q3VtsRE5dM\n",
+		"plain":     "alice:alice-password\n",
+		"no hash":   "alice\n",
+		"no user":   ":{SHA}hash\n",
+		"no users":  "# nobody\n\n",
+		"empty":     "",
+		"mixed bad": "alice:$2y$10$hash\nbob:$apr1$salt$hash\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(config.AuthConfig{Mode: config.AuthModeHtpasswd, HtpasswdFile: writeHtpasswd(t, content)}); err == nil {
+				t.Errorf("loaded the htpasswd file %q", content)
+			}
+		})
+	}
+	if _, err := New(config.AuthConfig{Mode: config.AuthModeHtpasswd, HtpasswdFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("loaded a missing htpasswd file")
+	}
+}
+
+func TestProxyAuthenticator(t *testing.T) {
+	proxy := config.AuthConfig{Mode: config.AuthModeProxy, ProxyUserHeader: "X-Forwarded-User", ProxyGroupsHeader: "X-Forwarded-Groups",
+		UploaderUsers: "alice", UploaderGroups: "cnv-admins, support"}
+	runAuthCases(t, proxy, []authCase{
+		{name: "uploader user", headers: map[string][]string{"X-Forwarded-User": {"alice"}}, user: "alice", role: Uploader},
+		{name: "uploader group", headers: map[string][]string{"X-Forwarded-User": {"bob"}, "X-Forwarded-Groups": {"developers,support"}},
+			user: "bob", role: Uploader},
+		{name: "group in a second header", headers: map[string][]string{"X-Forwarded-User": {"bob"}, "X-Forwarded-Groups": {"developers", "cnv-admins"}},
+			user: "bob", role: Uploader},
+		{name: "viewer groups", headers: map[string][]string{"X-Forwarded-User": {"carol"}, "X-Forwarded-Groups": {"developers, cnv-admins-readonly"}},
+			user: "carol", role: Viewer},
+		{name: "no groups", headers: map[string][]string{"X-Forwarded-User": {"carol"}}, user: "carol", role: Viewer},
+		// a group is not a user
+		{name: "user named as a group", headers: map[string][]string{"X-Forwarded-User": {"support"}}, user: "support", role: Viewer},
+		{name: "groups without user", headers: map[string][]string{"X-Forwarded-Groups": {"cnv-admins"}}, err: ErrNoCredentials},
+		{name: "basic auth", headers: basicAuth("alice", "alice-password"), err: ErrNoCredentials},
+	})
+
+	// the groups are ignored without a groups header
+	proxy.ProxyGroupsHeader = ""
+	runAuthCases(t, proxy, []authCase{
+		{name: "uploader group ignored", headers: map[string][]string{"X-Forwarded-User": {"bob"}, "X-Forwarded-Groups": {"support"}},
+			user: "bob", role: Viewer},
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, mode := range []string{"", config.AuthModeNone} {
+		authenticator, err := New(config.AuthConfig{Mode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		identity, err := authenticator.Authenticate(httptest.NewRequest(http.MethodPost, "/api/v1/imports", nil))
+		if err != nil || identity.Role != Uploader {
+			t.Errorf("mode %q authenticated as %v, %v, want an anonymous uploader", mode, identity, err)
+		}
+	}
+	if _, err := New(config.AuthConfig{Mode: "oauth"}); err == nil {
+		t.Error("an unsupported mode was accepted")
+	}
+}
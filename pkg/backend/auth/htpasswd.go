@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loadHtpasswd reads user:hash lines, only bcrypt and {SHA} hashes are supported
+func loadHtpasswd(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open htpasswd file: %w", err)
+	}
+	defer file.Close()
+
+	users := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, hash, found := strings.Cut(line, ":")
+		if !found || user == "" {
+			return nil, fmt.Errorf("%s:%d: expected user:hash", path, lineNumber)
+		}
+		if !isBcrypt(hash) && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("%s:%d: unsupported hash for user %q, use bcrypt (htpasswd -B)", path, lineNumber, user)
+		}
+		users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("htpasswd file %s has no users", path)
+	}
+	return users, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2y$") || strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$")
+}
+
+func checkPassword(hash string, password string) bool {
+	if isBcrypt(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	sum := sha1.Sum([]byte(password))
+	expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+}
@@ -6,6 +6,7 @@ import (
 	clientset "github.com/openshift/client-go/template/clientset/versioned"
 	"k8s.io/client-go/rest"
 
+	"logsviewer/pkg/backend/config"
 	"logsviewer/pkg/backend/log"
 )
 
@@ -15,13 +16,13 @@ type Cleanup struct {
 	client    *clientset.Clientset
 }
 
-func StartCleanupJob(instance, namespace string) {
-	if instance == "" || namespace == "" {
+func StartCleanupJob(cfg config.CleanupConfig) {
+	if cfg.Instance == "" || cfg.Namespace == "" {
 		log.Log.Println("Instance and namespace must be set (POD_NAME and POD_NAMESPACE env vars)")
 		return
 	}
 
-	cleanup := newCleanup(instance, namespace)
+	cleanup := newCleanup(cfg.Instance, cfg.Namespace)
 
 	timer := time.NewTimer(1 * time.Hour)
 	for {
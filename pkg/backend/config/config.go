@@ -0,0 +1,360 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"logsviewer/pkg/backend/db"
+)
+
+// Config holds the runtime settings of the logsviewer backend.
+// Settings are read, in increasing order of precedence, from the defaults,
+// an optional YAML file, the environment and the command line flags.
+type Config struct {
+	// ListenAddress is the address the HTTP server listens on
+	ListenAddress string `json:"listenAddress"`
+	// PublicDir contains the static web assets
+	PublicDir string `json:"publicDir"`
+	// DataDir is where uploaded must-gathers are extracted.
+	// It is shared with logstash, and emptied on every upload.
+	DataDir string `json:"dataDir"`
+	// EnrichmentDataFile is the logstash translate dictionary,
+	// it defaults to result.json in DataDir
+	EnrichmentDataFile string `json:"enrichmentDataFile"`
+	// TimestampFile is the must-gather timestamp file,
+	// it defaults to timestamp in DataDir
+	TimestampFile string `json:"timestampFile"`
+	// KibanaURL is the base URL of the Kibana API
+	KibanaURL string `json:"kibanaURL"`
+	// ElasticsearchURL is the base URL of the Elasticsearch API
+	ElasticsearchURL string `json:"elasticsearchURL"`
+	// LogstashURL is the base URL of the Logstash monitoring API
+	LogstashURL string `json:"logstashURL"`
+	// CORSAllowedOrigins is a comma separated list of origins allowed to call
+	// the API from a browser, only same-origin requests are allowed when empty
+	CORSAllowedOrigins string `json:"corsAllowedOrigins"`
+
+	Auth     AuthConfig     `json:"auth"`
+	Database DatabaseConfig `json:"database"`
+	Insights InsightsConfig `json:"insights"`
+	Cleanup  CleanupConfig  `json:"cleanup"`
+}
+
+const (
+	AuthModeNone     = "none"
+	AuthModeToken    = "token"
+	AuthModeHtpasswd = "htpasswd"
+	AuthModeProxy    = "proxy"
+)
+
+type AuthConfig struct {
+	// Mode is one of none, token, htpasswd or proxy
+	Mode string `json:"mode"`
+	// ViewerToken and UploaderToken are the static tokens of the token mode,
+	// the uploader token grants both roles
+	ViewerToken   string `json:"viewerToken"`
+	UploaderToken string `json:"uploaderToken"`
+	// HtpasswdFile holds the users of the htpasswd mode
+	HtpasswdFile string `json:"htpasswdFile"`
+	// ProxyUserHeader and ProxyGroupsHeader are set by the oauth-proxy in
+	// front of logsviewer in the proxy mode
+	ProxyUserHeader   string `json:"proxyUserHeader"`
+	ProxyGroupsHeader string `json:"proxyGroupsHeader"`
+	// UploaderUsers and UploaderGroups are comma separated lists of who may
+	// upload in the htpasswd and proxy modes. When both are empty every
+	// authenticated user may upload.
+	UploaderUsers  string `json:"uploaderUsers"`
+	UploaderGroups string `json:"uploaderGroups"`
+}
+
+type DatabaseConfig struct {
+	// Driver is either mysql or sqlite
+	Driver string      `json:"driver"`
+	MySQL  MySQLConfig `json:"mysql"`
+	// SQLitePath is the SQLite database file, it must not live in DataDir
+	SQLitePath string `json:"sqlitePath"`
+}
+
+type MySQLConfig struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Database string `json:"database"`
+}
+
+type InsightsConfig struct {
+	// BinaryPath is the insights-client binary, insights are disabled when empty
+	BinaryPath string `json:"binaryPath"`
+	// Plugin is the insights rules plugin to run
+	Plugin string `json:"plugin"`
+}
+
+type CleanupConfig struct {
+	// Instance and Namespace identify the template of this logsviewer
+	// instance, the cleanup job is disabled when either is empty
+	Instance  string `json:"instance"`
+	Namespace string `json:"namespace"`
+}
+
+// Default returns the settings matching the logsviewer pod template
+func Default() *Config {
+	return &Config{
+		ListenAddress:    ":8080",
+		PublicDir:        "./frontend/build/",
+		DataDir:          "/space",
+		KibanaURL:        "http://localhost:5601",
+		ElasticsearchURL: "http://localhost:9200",
+		LogstashURL:      "http://localhost:9600",
+		Auth: AuthConfig{
+			Mode:              AuthModeNone,
+			ProxyUserHeader:   "X-Forwarded-User",
+			ProxyGroupsHeader: "X-Forwarded-Groups",
+		},
+		Database: DatabaseConfig{
+			Driver: db.MySQLDriver,
+			MySQL: MySQLConfig{
+				User:     "mysql",
+				Host:     "127.0.0.1",
+				Port:     "3306",
+				Database: "objtracker",
+			},
+			SQLitePath: "./logsviewer.db",
+		},
+		Insights: InsightsConfig{
+			Plugin: "ccx_rules_ocp",
+		},
+	}
+}
+
+// setting binds a single string field to a flag and an environment variable.
+// An empty flag or env means the setting can't be set that way.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *Config) *string
+}
+
+var settings = []setting{
+	{"listen-address", "LOGSVIEWER_LISTEN_ADDRESS", "address the HTTP server listens on.", func(c *Config) *string { return &c.ListenAddress }},
+	{"public-dir", "LOGSVIEWER_PUBLIC_DIR", "directory containing static web assets.", func(c *Config) *string { return &c.PublicDir }},
+	{"data-dir", "LOGSVIEWER_DATA_DIR", "directory uploaded must-gathers are extracted to.", func(c *Config) *string { return &c.DataDir }},
+	{"enrichment-data-file", "LOGSVIEWER_ENRICHMENT_DATA_FILE", "logstash enrichment dictionary, defaults to result.json in the data dir.", func(c *Config) *string { return &c.EnrichmentDataFile }},
+	{"timestamp-file", "LOGSVIEWER_TIMESTAMP_FILE", "must-gather timestamp file, defaults to timestamp in the data dir.", func(c *Config) *string { return &c.TimestampFile }},
+	{"kibana-url", "LOGSVIEWER_KIBANA_URL", "base URL of the Kibana API.", func(c *Config) *string { return &c.KibanaURL }},
+	{"elasticsearch-url", "LOGSVIEWER_ELASTICSEARCH_URL", "base URL of the Elasticsearch API.", func(c *Config) *string { return &c.ElasticsearchURL }},
+	{"logstash-url", "LOGSVIEWER_LOGSTASH_URL", "base URL of the Logstash monitoring API.", func(c *Config) *string { return &c.LogstashURL }},
+	{"cors-allowed-origins", "LOGSVIEWER_CORS_ALLOWED_ORIGINS", "comma separated origins allowed to call the API from a browser.", func(c *Config) *string { return &c.CORSAllowedOrigins }},
+	{"auth-mode", "LOGSVIEWER_AUTH_MODE", "authentication mode: none, token, htpasswd or proxy.", func(c *Config) *string { return &c.Auth.Mode }},
+	{"", "LOGSVIEWER_VIEWER_TOKEN", "", func(c *Config) *string { return &c.Auth.ViewerToken }},
+	{"", "LOGSVIEWER_UPLOADER_TOKEN", "", func(c *Config) *string { return &c.Auth.UploaderToken }},
+	{"htpasswd-file", "LOGSVIEWER_HTPASSWD_FILE", "htpasswd file, used with -auth-mode=htpasswd.", func(c *Config) *string { return &c.Auth.HtpasswdFile }},
+	{"auth-proxy-user-header", "LOGSVIEWER_AUTH_PROXY_USER_HEADER", "header carrying the user, used with -auth-mode=proxy.", func(c *Config) *string { return &c.Auth.ProxyUserHeader }},
+	{"auth-proxy-groups-header", "LOGSVIEWER_AUTH_PROXY_GROUPS_HEADER", "header carrying the user groups, used with -auth-mode=proxy.", func(c *Config) *string { return &c.Auth.ProxyGroupsHeader }},
+	{"uploader-users", "LOGSVIEWER_UPLOADER_USERS", "comma separated users allowed to upload, everyone when empty.", func(c *Config) *string { return &c.Auth.UploaderUsers }},
+	{"uploader-groups", "LOGSVIEWER_UPLOADER_GROUPS", "comma separated groups allowed to upload, used with -auth-mode=proxy.", func(c *Config) *string { return &c.Auth.UploaderGroups }},
+	{"db-driver", "DB_DRIVER", "database backend to use: mysql or sqlite.", func(c *Config) *string { return &c.Database.Driver }},
+	{"sqlite-path", "SQLITE_PATH", "path to the SQLite database file, used with -db-driver=sqlite.", func(c *Config) *string { return &c.Database.SQLitePath }},
+	{"mysql-user", "MYSQL_USER", "MySQL user.", func(c *Config) *string { return &c.Database.MySQL.User }},
+	{"", "MYSQL_PASSWORD", "", func(c *Config) *string { return &c.Database.MySQL.Password }},
+	{"mysql-host", "MYSQL_HOST", "MySQL host.", func(c *Config) *string { return &c.Database.MySQL.Host }},
+	{"mysql-port", "MYSQL_PORT", "MySQL port.", func(c *Config) *string { return &c.Database.MySQL.Port }},
+	{"mysql-database", "MYSQL_DATABASE", "MySQL database name.", func(c *Config) *string { return &c.Database.MySQL.Database }},
+	{"insights-binary-path", "INSIGHTS_BINARY_PATH", "path to the insights-client binary.", func(c *Config) *string { return &c.Insights.BinaryPath }},
+	{"insights-plugin", "INSIGHTS_PLUGIN", "insights rules plugin to run.", func(c *Config) *string { return &c.Insights.Plugin }},
+	{"", "POD_NAME", "", func(c *Config) *string { return &c.Cleanup.Instance }},
+	{"", "POD_NAMESPACE", "", func(c *Config) *string { return &c.Cleanup.Namespace }},
+}
+
+// Load builds and validates the configuration from the command line arguments,
+// the environment and the YAML file passed with -config or LOGSVIEWER_CONFIG.
+func Load(args []string) (*Config, error) {
+	defaults := Default()
+
+	fs := flag.NewFlagSet("logsviewer", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("LOGSVIEWER_CONFIG"), "optional YAML configuration file.")
+	byFlag := map[string]setting{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		fs.String(s.flag, *s.field(defaults), s.usage)
+		byFlag[s.flag] = s
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaults
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, exists := os.LookupEnv(s.env); exists {
+			*s.field(cfg) = value
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if s, exists := byFlag[f.Name]; exists {
+			*s.field(cfg) = f.Value.String()
+		}
+	})
+
+	if cfg.EnrichmentDataFile == "" {
+		cfg.EnrichmentDataFile = filepath.Join(cfg.DataDir, "result.json")
+	}
+	if cfg.TimestampFile == "" {
+		cfg.TimestampFile = filepath.Join(cfg.DataDir, "timestamp")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []string
+
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid listen address %q: %v", c.ListenAddress, err))
+	}
+	if c.PublicDir == "" {
+		errs = append(errs, "public dir must be set")
+	}
+	if !filepath.IsAbs(c.DataDir) {
+		errs = append(errs, fmt.Sprintf("data dir %q must be an absolute path", c.DataDir))
+	}
+	for _, endpoint := range []struct{ name, url string }{
+		{"kibana", c.KibanaURL},
+		{"elasticsearch", c.ElasticsearchURL},
+		{"logstash", c.LogstashURL},
+	} {
+		if !isHTTPURL(endpoint.url) {
+			errs = append(errs, fmt.Sprintf("invalid %s URL %q", endpoint.name, endpoint.url))
+		}
+	}
+
+	for _, origin := range SplitList(c.CORSAllowedOrigins) {
+		if origin != "*" && !isHTTPURL(origin) {
+			errs = append(errs, fmt.Sprintf("invalid CORS origin %q", origin))
+		}
+	}
+
+	switch c.Auth.Mode {
+	case AuthModeNone:
+	case AuthModeToken:
+		if c.Auth.UploaderToken == "" {
+			errs = append(errs, "the uploader token must be set in the token auth mode")
+		} else if c.Auth.UploaderToken == c.Auth.ViewerToken {
+			errs = append(errs, "the viewer and uploader tokens must differ")
+		}
+	case AuthModeHtpasswd:
+		if c.Auth.HtpasswdFile == "" {
+			errs = append(errs, "the htpasswd file must be set in the htpasswd auth mode")
+		}
+	case AuthModeProxy:
+		if c.Auth.ProxyUserHeader == "" {
+			errs = append(errs, "the proxy user header must be set in the proxy auth mode")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported auth mode %q, expected %s, %s, %s or %s", c.Auth.Mode, AuthModeNone, AuthModeToken, AuthModeHtpasswd, AuthModeProxy))
+	}
+
+	switch c.Database.Driver {
+	case db.MySQLDriver:
+		if c.Database.MySQL.Host == "" || c.Database.MySQL.Database == "" || c.Database.MySQL.User == "" {
+			errs = append(errs, "mysql user, host and database must be set")
+		}
+		if _, err := strconv.ParseUint(c.Database.MySQL.Port, 10, 16); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid mysql port %q", c.Database.MySQL.Port))
+		}
+	case db.SQLiteDriver:
+		if c.Database.SQLitePath == "" {
+			errs = append(errs, "sqlite path must be set")
+		} else if isWithin(c.DataDir, c.Database.SQLitePath) {
+			errs = append(errs, fmt.Sprintf("sqlite path %q must not be in the data dir, which is emptied on every upload", c.Database.SQLitePath))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("unsupported database driver %q, expected %s or %s", c.Database.Driver, db.MySQLDriver, db.SQLiteDriver))
+	}
+
+	if c.Insights.BinaryPath != "" && c.Insights.Plugin == "" {
+		errs = append(errs, "insights plugin must be set when the insights binary is")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// StoreOptions returns the options to connect to the configured database
+func (c *Config) StoreOptions() db.StoreOptions {
+	return db.StoreOptions{
+		Driver: c.Database.Driver,
+		MySQL: db.MySQLOptions{
+			Username: c.Database.MySQL.User,
+			Password: c.Database.MySQL.Password,
+			Host:     c.Database.MySQL.Host,
+			Port:     c.Database.MySQL.Port,
+			DBName:   c.Database.MySQL.Database,
+		},
+		SQLitePath: c.Database.SQLitePath,
+	}
+}
+
+// SplitList splits a comma separated setting, dropping empty items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isWithin(dir string, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,234 @@
+package correlation
+
+import (
+	"sort"
+	"time"
+
+	"logsviewer/pkg/backend/db"
+)
+
+// Index is the pattern of the indices logstash writes the logs to
+const Index = "cnvlogs-*"
+
+// DefaultPadding is how long before the object creation queries start,
+// so the events leading to the creation are included
+const DefaultPadding = 30 * time.Second
+
+// ClusterField is the enrichment field holding the cluster of a log line
+const ClusterField = "enrichment_data.cluster"
+
+// PodUIDField is the enrichment field holding the pod UID of a log line
+const PodUIDField = "enrichment_data.pod.uid"
+
+// ImportField is the enrichment field holding the name of the import a log
+// line was ingested by
+const ImportField = "enrichment_data.import"
+
+var (
+	// DefaultColumns are the log fields shown by default
+	DefaultColumns = []string{"msg", "podName", "component", "uid", "subcomponent", "reason", "enrichment_data.pod.uid", "enrichment_data.host.name", "level"}
+
+	// ColumnSets are the column sets a query can be rendered with
+	ColumnSets = map[string][]string{
+		"default": DefaultColumns,
+		"compact": {"msg", "podName", "level"},
+		"verbose": {"msg", "level", "namespace", "podName", "containerName", "component", "subcomponent", "kind", "name", "uid", "reason", "pos", "enrichment_data.pod.uid", "enrichment_data.pod.ownerKinds", "enrichment_data.host.name", "enrichment_data.host.ip"},
+	}
+
+	controllerContainers = []string{"virt-controller", "virt-api"}
+
+	storageControllerContainers = []string{"cdi-controller"}
+
+	operatorContainers = []string{"hyperconverged-cluster-operator", "virt-operator", "cdi-operator", "olm-operator", "catalog-operator"}
+)
+
+// ColumnSetNames lists the column sets in a stable order
+func ColumnSetNames() []string {
+	names := make([]string, 0, len(ColumnSets))
+	for name := range ColumnSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clause matches a phrase in a single field, or in any field when Field is empty
+type Clause struct {
+	Field string `json:"field,omitempty"`
+	Value string `json:"value"`
+}
+
+// Query correlates the log lines related to an object. A line matches
+// when any of the clauses does, within the time range and the cluster, and
+// none of the exclusions does.
+type Query struct {
+	From time.Time `json:"from"`
+	// To is the end of the time range, a zero To means now
+	To         time.Time `json:"to"`
+	Clauses    []Clause  `json:"clauses"`
+	Exclusions []string  `json:"exclusions"`
+	Columns    []string  `json:"columns"`
+	// Cluster only matches the log lines enriched with this cluster, any
+	// cluster is matched when empty
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// New returns an empty query over all time, with the default columns
+func New() *Query {
+	return &Query{
+		Exclusions: []string{},
+		Columns:    append([]string{}, DefaultColumns...),
+	}
+}
+
+// Between bounds the query in time, a zero end means now
+func (q *Query) Between(start time.Time, end time.Time) *Query {
+	q.From = start
+	q.To = end
+	return q
+}
+
+// Pad widens the time range, an end of now isn't moved
+func (q *Query) Pad(before time.Duration, after time.Duration) *Query {
+	if !q.From.IsZero() {
+		q.From = q.From.Add(-before)
+	}
+	if !q.To.IsZero() {
+		q.To = q.To.Add(after)
+	}
+	return q
+}
+
+// Match adds a clause per non empty value of field
+func (q *Query) Match(field string, values ...string) *Query {
+	for _, value := range values {
+		if value != "" {
+			q.Clauses = append(q.Clauses, Clause{Field: field, Value: value})
+		}
+	}
+	return q
+}
+
+// MatchAny adds a clause per non empty value, matched in any field
+func (q *Query) MatchAny(values ...string) *Query {
+	return q.Match("", values...)
+}
+
+// Exclude filters out the log lines whose msg contains any of the phrases
+func (q *Query) Exclude(phrases ...string) *Query {
+	q.Exclusions = append(q.Exclusions, phrases...)
+	return q
+}
+
+// InCluster restricts the query to the log lines of a cluster
+func (q *Query) InCluster(cluster string) *Query {
+	q.Cluster = cluster
+	return q
+}
+
+// WithColumns replaces the columns shown in Kibana and returned by the search
+func (q *Query) WithColumns(columns ...string) *Query {
+	q.Columns = append([]string{}, columns...)
+	return q
+}
+
+func (q *Query) matchControllers() *Query {
+	return q.Match("containerName", controllerContainers...)
+}
+
+// ForPod correlates the logs of a single pod
+func ForPod(res db.QueryResults) *Query {
+	return New().Between(res.StartTimestamp, res.EndTimestamp).
+		Match("podName", res.SourcePod).
+		MatchAny(res.SourcePodUUID).
+		MatchAny(res.PVCs...)
+}
+
+// ForVMI correlates the logs of a VMI on a single node
+func ForVMI(res db.QueryResults) *Query {
+	return New().Between(res.StartTimestamp, res.EndTimestamp).
+		matchControllers().
+		Match("podName", res.SourcePod, res.SourceHandler).
+		MatchAny(res.SourcePodUUID, res.VMIUUID).
+		MatchAny(res.PVCs...)
+}
+
+// ForVMIHistory correlates the logs of a VMI across every node it ran on
+func ForVMIHistory(res db.QueryResults) *Query {
+	involved := append(append([]db.Pod{}, res.InvolvedVirtLaunchers...), res.InvolvedVirtHandlers...)
+	q := New().Between(res.StartTimestamp, res.EndTimestamp).matchControllers()
+	for _, pod := range involved {
+		q.Match("podName", pod.Name)
+	}
+	for _, pod := range involved {
+		q.MatchAny(pod.UUID)
+	}
+	return q.MatchAny(res.PVCs...).MatchAny(res.VMIUUID)
+}
+
+// ForMigration correlates the logs of a migration
+func ForMigration(res db.QueryResults) *Query {
+	return New().Between(res.StartTimestamp, res.EndTimestamp).
+		matchControllers().
+		Match("podName", res.SourcePod, res.SourceHandler, res.TargetPod, res.TargetHandler).
+		MatchAny(res.SourcePodUUID, res.VMIUUID, res.TargetPodUUID, res.MigrationUUID).
+		MatchAny(res.PVCs...)
+}
+
+// ForNode correlates the logs of a node, its virt-handler and the
+// virt-launchers scheduled on it, and the controllers acting on them
+func ForNode(res db.QueryResults) *Query {
+	involved := append(append([]db.Pod{}, res.InvolvedVirtHandlers...), res.InvolvedVirtLaunchers...)
+	q := New().Between(res.StartTimestamp, res.EndTimestamp).matchControllers()
+	for _, pod := range involved {
+		q.Match("podName", pod.Name)
+	}
+	q.Match("enrichment_data.host.name", res.NodeName).MatchAny(res.NodeName)
+	for _, pod := range involved {
+		q.MatchAny(pod.UUID)
+	}
+	return q
+}
+
+// ForPVC correlates the logs of a claim, the CDI and CSI pods provisioning
+// it and the virt-launchers mounting it
+func ForPVC(res db.QueryResults) *Query {
+	involved := append(append(append([]db.Pod{}, res.InvolvedCDIPods...), res.InvolvedCSIPods...), res.InvolvedVirtLaunchers...)
+	q := New().Between(res.StartTimestamp, res.EndTimestamp).
+		Match("containerName", storageControllerContainers...)
+	for _, pod := range involved {
+		q.Match("podName", pod.Name)
+	}
+	q.MatchAny(res.PVCName).MatchAny(res.PVCs...).MatchAny(res.DataVolumeUUID)
+	for _, pod := range involved {
+		q.MatchAny(pod.UUID)
+	}
+	return q
+}
+
+// ForOperator correlates the logs of an operator install or upgrade, the
+// HCO, KubeVirt, CDI and OLM operators and the catalog pods serving it
+func ForOperator(res db.QueryResults) *Query {
+	q := New().Between(res.StartTimestamp, res.EndTimestamp).
+		Match("containerName", operatorContainers...)
+	for _, pod := range res.InvolvedOperatorPods {
+		q.Match("podName", pod.Name)
+	}
+	q.MatchAny(res.CSVNames...).MatchAny(res.InstallPlanNames...)
+	for _, pod := range res.InvolvedOperatorPods {
+		q.MatchAny(pod.UUID)
+	}
+	return q
+}
+
+// ForNamespace correlates the logs of every pod and VMI of a namespace, the
+// virt-handlers of the nodes the pods ran on and the controllers
+func ForNamespace(res db.QueryResults) *Query {
+	q := New().Between(res.StartTimestamp, res.EndTimestamp).
+		matchControllers().
+		Match("namespace", res.Namespace)
+	for _, pod := range res.InvolvedVirtHandlers {
+		q.Match("podName", pod.Name)
+	}
+	return q.MatchAny(res.VMIUUIDs...)
+}
@@ -0,0 +1,172 @@
+package correlation
+
+import (
+	"strings"
+	"time"
+)
+
+const timestampLayout = "2006-01-02T15:04:05.000Z"
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// KQL renders the clauses as a Kibana query language expression,
+// values are always quoted so they can't change the expression
+func (q *Query) KQL() string {
+	clauses := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		if clause.Field == "" {
+			clauses = append(clauses, kqlQuote(clause.Value))
+		} else {
+			clauses = append(clauses, kqlField(clause.Field)+": "+kqlQuote(clause.Value))
+		}
+	}
+	return strings.Join(clauses, " or ")
+}
+
+// kqlQuote quotes a phrase, only \ and " need escaping within quotes
+func kqlQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// kqlField escapes the characters KQL gives a meaning to in a field name
+func kqlField(field string) string {
+	var b strings.Builder
+	for _, r := range field {
+		if strings.ContainsRune(`\():<>"*{} `, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Search renders the query as the body of an Elasticsearch _search request
+func (q *Query) Search() map[string]interface{} {
+	timeRange := map[string]interface{}{
+		"lte":    "now",
+		"format": "strict_date_optional_time",
+	}
+	if !q.From.IsZero() {
+		timeRange["gte"] = formatTimestamp(q.From)
+	}
+	if !q.To.IsZero() {
+		timeRange["lte"] = formatTimestamp(q.To)
+	}
+
+	should := make([]interface{}, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		if clause.Field == "" {
+			should = append(should, map[string]interface{}{
+				"multi_match": map[string]interface{}{"query": clause.Value, "type": "phrase", "lenient": true},
+			})
+		} else {
+			should = append(should, map[string]interface{}{
+				"match_phrase": map[string]interface{}{clause.Field: clause.Value},
+			})
+		}
+	}
+
+	mustNot := make([]interface{}, 0, len(q.Exclusions))
+	for _, phrase := range q.Exclusions {
+		mustNot = append(mustNot, map[string]interface{}{
+			"match_phrase": map[string]interface{}{"msg": phrase},
+		})
+	}
+
+	filter := []interface{}{
+		map[string]interface{}{"range": map[string]interface{}{"@timestamp": timeRange}},
+		map[string]interface{}{"exists": map[string]interface{}{"field": "msg"}},
+	}
+	if q.Cluster != "" {
+		filter = append(filter, map[string]interface{}{"match_phrase": map[string]interface{}{ClusterField: q.Cluster}})
+	}
+	boolQuery := map[string]interface{}{
+		"filter":   filter,
+		"must_not": mustNot,
+	}
+	if len(should) > 0 {
+		boolQuery["should"] = should
+		boolQuery["minimum_should_match"] = 1
+	}
+
+	return map[string]interface{}{
+		"query":   map[string]interface{}{"bool": boolQuery},
+		"sort":    []interface{}{map[string]interface{}{"@timestamp": map[string]interface{}{"order": "asc"}}},
+		"_source": append([]string{"@timestamp"}, q.Columns...),
+	}
+}
+
+// ImportLogs renders the body of an Elasticsearch request matching every
+// log line ingested by an import, such as a _delete_by_query, and every line
+// of the pods, which matches the lines ingested before they were tagged with
+// their import
+func ImportLogs(name string, uids []string) map[string]interface{} {
+	should := make([]interface{}, 0, len(uids)+1)
+	if name != "" {
+		should = append(should, map[string]interface{}{
+			"match_phrase": map[string]interface{}{ImportField: name},
+		})
+	}
+	for _, uid := range uids {
+		should = append(should, map[string]interface{}{
+			"match_phrase": map[string]interface{}{PodUIDField: uid},
+		})
+	}
+	return map[string]interface{}{
+		"query": map[string]interface{}{"bool": map[string]interface{}{"should": should, "minimum_should_match": 1}},
+	}
+}
+
+// KibanaURL renders the query as the _g and _a parameters of a Kibana Discover URL
+func (q *Query) KibanaURL() string {
+	from, to := "now-15y", "now"
+	if !q.From.IsZero() {
+		from = formatTimestamp(q.From)
+	}
+	if !q.To.IsZero() {
+		to = formatTimestamp(q.To)
+	}
+	global := map[string]interface{}{
+		"filters":         []interface{}{},
+		"refreshInterval": map[string]interface{}{"pause": true, "value": 0},
+		"time":            map[string]interface{}{"from": from, "to": to},
+	}
+
+	filters := []interface{}{kibanaFilter("msg", false, map[string]interface{}{"type": "exists", "value": "exists"},
+		map[string]interface{}{"exists": map[string]interface{}{"field": "msg"}})}
+	if q.Cluster != "" {
+		filters = append(filters, kibanaFilter(ClusterField, false, map[string]interface{}{"type": "phrase", "params": map[string]interface{}{"query": q.Cluster}},
+			map[string]interface{}{"match_phrase": map[string]interface{}{ClusterField: q.Cluster}}))
+	}
+	for _, phrase := range q.Exclusions {
+		filters = append(filters, kibanaFilter("msg", true, map[string]interface{}{"type": "phrase", "params": map[string]interface{}{"query": phrase}},
+			map[string]interface{}{"match_phrase": map[string]interface{}{"msg": phrase}}))
+	}
+	app := map[string]interface{}{
+		"columns":  q.Columns,
+		"filters":  filters,
+		"interval": "auto",
+		"query":    map[string]interface{}{"language": "kuery", "query": q.KQL()},
+		"sort":     []interface{}{[]string{"@timestamp", "asc"}},
+	}
+
+	return "_g=" + escapeRisonURL(rison(global)) + "&_a=" + escapeRisonURL(rison(app))
+}
+
+// kibanaFilter builds a Discover filter pill, meta describes the pill
+// and query is the Elasticsearch query it applies
+func kibanaFilter(key string, negate bool, meta map[string]interface{}, query map[string]interface{}) map[string]interface{} {
+	meta["alias"] = nil
+	meta["disabled"] = false
+	meta["key"] = key
+	meta["negate"] = negate
+	return map[string]interface{}{
+		"$state": map[string]interface{}{"store": "appState"},
+		"meta":   meta,
+		"query":  query,
+	}
+}
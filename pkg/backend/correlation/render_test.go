@@ -0,0 +1,227 @@
+package correlation
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"logsviewer/pkg/backend/db"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the rendered queries")
+
+var (
+	created  = time.Date(2023, 5, 4, 10, 0, 0, 0, time.UTC)
+	finished = time.Date(2023, 5, 4, 10, 30, 0, 123000000, time.UTC)
+)
+
+// goldenQueries are rendered by every renderer and compared with
+// testdata/<name>.<renderer>.golden
+var goldenQueries = map[string]*Query{
+	"pod": ForPod(db.QueryResults{
+		SourcePod:      "virt-launcher-fedora-x7k2p",
+		SourcePodUUID:  "6f1c2a4e-0d7e-4a8a-9d43-1f1a7d3c2b10",
+		StartTimestamp: created,
+		PVCs:           []string{"fedora-rootdisk"},
+	}).InCluster("cluster-a"),
+	"vmi": ForVMI(db.QueryResults{
+		SourcePod:      "virt-launcher-fedora-x7k2p",
+		SourcePodUUID:  "6f1c2a4e-0d7e-4a8a-9d43-1f1a7d3c2b10",
+		SourceHandler:  "virt-handler-9tq4m",
+		VMIUUID:        "0b5e8a55-3c55-4b8e-a3c9-0fd0b9f0e1aa",
+		StartTimestamp: created,
+		EndTimestamp:   finished,
+		PVCs:           []string{"fedora-rootdisk", "fedora-data"},
+	}).Pad(DefaultPadding, 0).Exclude("certificate with common name 'kubevirt.io:system:client:virt-handler' retrieved."),
+	"vmihistory": ForVMIHistory(db.QueryResults{
+		VMIUUID:        "0b5e8a55-3c55-4b8e-a3c9-0fd0b9f0e1aa",
+		StartTimestamp: created,
+		EndTimestamp:   finished,
+		InvolvedVirtLaunchers: []db.Pod{
+			{Name: "virt-launcher-fedora-x7k2p", UUID: "6f1c2a4e-0d7e-4a8a-9d43-1f1a7d3c2b10"},
+			{Name: "virt-launcher-fedora-q8w3z", UUID: "8a2d3b5f-1e8f-4b9b-8e54-2a2b8e4d3c21"},
+		},
+		InvolvedVirtHandlers: []db.Pod{
+			{Name: "virt-handler-9tq4m", UUID: "1c3e5a7b-2f9a-4cac-9f65-3b3c9f5e4d32"},
+		},
+	}).WithColumns(ColumnSets["compact"]...),
+	"migration": ForMigration(db.QueryResults{
+		SourcePod:      "virt-launcher-fedora-x7k2p",
+		SourcePodUUID:  "6f1c2a4e-0d7e-4a8a-9d43-1f1a7d3c2b10",
+		SourceHandler:  "virt-handler-9tq4m",
+		TargetPod:      "virt-launcher-fedora-q8w3z",
+		TargetPodUUID:  "8a2d3b5f-1e8f-4b9b-8e54-2a2b8e4d3c21",
+		TargetHandler:  "virt-handler-b7r2d",
+		VMIUUID:        "0b5e8a55-3c55-4b8e-a3c9-0fd0b9f0e1aa",
+		MigrationUUID:  "4d6f8b0c-3a0b-4dbd-a076-4c4d0a6f5e43",
+		StartTimestamp: created,
+		EndTimestamp:   finished,
+	}).InCluster("cluster-a"),
+	// escaping renders values KQL and rison give a meaning to
+	"escaping": ForPod(db.QueryResults{
+		SourcePod:      `it's "quoted"`,
+		SourcePodUUID:  `back\slash`,
+		StartTimestamp: created,
+		PVCs:           []string{"bang!", `'!'`},
+	}).InCluster("o'brien!").Exclude(`say "hi" \ it's!`),
+}
+
+// renderers render a query as the content of its golden file
+var renderers = map[string]func(t *testing.T, q *Query) []byte{
+	"kql": func(t *testing.T, q *Query) []byte {
+		return []byte(q.KQL() + "\n")
+	},
+	"search": func(t *testing.T, q *Query) []byte {
+		content, err := json.MarshalIndent(q.Search(), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(content, '\n')
+	},
+	"kibana": func(t *testing.T, q *Query) []byte {
+		return []byte(q.KibanaURL() + "\n")
+	},
+}
+
+func TestRenderGolden(t *testing.T) {
+	for name, q := range goldenQueries {
+		for renderer, render := range renderers {
+			t.Run(name+"/"+renderer, func(t *testing.T) {
+				got := render(t, q)
+				golden := filepath.Join("testdata", name+"."+renderer+".golden")
+				if *update {
+					if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("failed to read the golden file, run the tests with -update to create it: %v", err)
+				}
+				if string(got) != string(want) {
+					t.Errorf("%s differs from %s:\ngot:\n%s\nwant:\n%s", renderer, golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestImportLogs(t *testing.T) {
+	tests := []struct {
+		name   string
+		uids   []string
+		fields []string
+	}{
+		{name: "older.tar.gz", uids: []string{"uid-1", "uid-2"}, fields: []string{ImportField, PodUIDField, PodUIDField}},
+		// a duplicate gather removes no pod
+		{name: "duplicate.tar.gz", fields: []string{ImportField}},
+		{uids: []string{"uid-1"}, fields: []string{PodUIDField}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := json.Marshal(ImportLogs(tt.name, tt.uids))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := struct {
+				Query struct {
+					Bool struct {
+						Should             []map[string]map[string]string `json:"should"`
+						MinimumShouldMatch int                            `json:"minimum_should_match"`
+					} `json:"bool"`
+				} `json:"query"`
+			}{}
+			if err := json.Unmarshal(content, &body); err != nil {
+				t.Fatal(err)
+			}
+			values := append([]string{tt.name}, tt.uids...)
+			if tt.name == "" {
+				values = tt.uids
+			}
+			should := body.Query.Bool.Should
+			if len(should) != len(tt.fields) || body.Query.Bool.MinimumShouldMatch != 1 {
+				t.Fatalf("rendered %s, want a single match of %d clauses", content, len(tt.fields))
+			}
+			for i, field := range tt.fields {
+				if should[i]["match_phrase"][field] != values[i] {
+					t.Errorf("clause %d is %v, want %s matching %q", i, should[i], field, values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKQLQuote(t *testing.T) {
+	for value, want := range map[string]string{
+		"plain":         `"plain"`,
+		`say "hi"`:      `"say \"hi\""`,
+		`back\slash`:    `"back\\slash"`,
+		`\"`:            `"\\\""`,
+		`it's!`:         `"it's!"`,
+		"or and not: *": `"or and not: *"`,
+		"":              `""`,
+	} {
+		if quoted := kqlQuote(value); quoted != want {
+			t.Errorf("kqlQuote(%q) = %s, want %s", value, quoted, want)
+		}
+	}
+}
+
+func TestKQLField(t *testing.T) {
+	for field, want := range map[string]string{
+		"enrichment_data.pod.uid": "enrichment_data.pod.uid",
+		`a b`:                     `a\ b`,
+		`x:y`:                     `x\:y`,
+		`*"\`:                     `\*\"\\`,
+	} {
+		if escaped := kqlField(field); escaped != want {
+			t.Errorf("kqlField(%q) = %s, want %s", field, escaped, want)
+		}
+	}
+}
+
+func TestRison(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"identifier", "msg", "msg"},
+		{"empty string", "", "''"},
+		{"leading digit", "1abc", "'1abc'"},
+		{"leading dash", "-abc", "'-abc'"},
+		{"space", "a b", "'a b'"},
+		{"quote", "it's", "'it!'s'"},
+		{"bang", "bang!", "'bang!!'"},
+		{"quoted bangs", `'!'`, "'!'!!!''"},
+		{"double quote and backslash", `say "hi" \`, `'say "hi" \'`},
+		{"non ascii", "é", "'é'"},
+		{"literals", []interface{}{nil, true, false, 42}, "!(!n,!t,!f,42)"},
+		{"string list", []string{"msg", "it's"}, "!(msg,'it!'s')"},
+		{"sorted keys", map[string]interface{}{"b": 1, "a": "x y", "it's": nil}, "(a:'x y',b:1,'it!'s':!n)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if encoded := rison(tt.value); encoded != tt.want {
+				t.Errorf("rison(%#v) = %s, want %s", tt.value, encoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeRisonURL(t *testing.T) {
+	for value, want := range map[string]string{
+		"(a:'x y',b:!t)": "(a:'x%20y',b:!t)",
+		`"\&#%+=?`:       "%22%5C%26%23%25%2B%3D%3F",
+		"é":              "%C3%A9",
+		"-_.~!'()*,:@$/": "-_.~!'()*,:@$/",
+	} {
+		if escaped := escapeRisonURL(value); escaped != want {
+			t.Errorf("escapeRisonURL(%q) = %s, want %s", value, escaped, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package correlation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	risonNotIDChar  = " '!:(),*@$"
+	risonNotIDStart = "-0123456789"
+)
+
+// rison encodes a value as rison, the URL friendly JSON Kibana keeps its
+// state in. Object keys are sorted like the reference encoder does.
+func rison(value interface{}) string {
+	var b strings.Builder
+	writeRison(&b, value)
+	return b.String()
+}
+
+func writeRison(b *strings.Builder, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("!n")
+	case bool:
+		if v {
+			b.WriteString("!t")
+		} else {
+			b.WriteString("!f")
+		}
+	case int:
+		b.WriteString(strconv.Itoa(v))
+	case string:
+		b.WriteString(risonString(v))
+	case []string:
+		b.WriteString("!(")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(risonString(item))
+		}
+		b.WriteByte(')')
+	case []interface{}:
+		b.WriteString("!(")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeRison(b, item)
+		}
+		b.WriteByte(')')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteByte('(')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(risonString(key))
+			b.WriteByte(':')
+			writeRison(b, v[key])
+		}
+		b.WriteByte(')')
+	default:
+		panic(fmt.Sprintf("rison: unsupported type %T", value))
+	}
+}
+
+// risonString leaves identifiers bare and quotes everything else,
+// escaping ! and ' with a !
+func risonString(s string) string {
+	if isRisonID(s) {
+		return s
+	}
+	s = strings.ReplaceAll(s, "!", "!!")
+	s = strings.ReplaceAll(s, "'", "!'")
+	return "'" + s + "'"
+}
+
+func isRisonID(s string) bool {
+	if s == "" || strings.ContainsRune(risonNotIDStart, rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e || strings.ContainsRune(risonNotIDChar, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeRisonURL percent-encodes rison for a URL query, keeping the
+// characters rison relies on readable
+func escapeRisonURL(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			strings.IndexByte("-_.~!'()*,:@$/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"time"
+
+	"logsviewer/pkg/backend/correlation"
+	"logsviewer/pkg/backend/db"
+)
+
+// correlationResponse renders a correlation query, within the requested
+// window and without the noise of the enabled filters, as a Kibana Discover
+// URL, a KQL expression and an Elasticsearch search. dslQuery keeps its
+// historical name, it holds the _g and _a Discover parameters. warnings
+// name the components the query was built without.
+func (c *app) correlationResponse(req *apiRequest, q *correlation.Query, warnings []string) (interface{}, error) {
+	if q.To.IsZero() {
+		// the object was still around when the must-gather was collected
+		gatherTime, err := c.store(req).LatestGatherTime()
+		if err != nil {
+			return nil, err
+		}
+		if gatherTime.After(q.From) {
+			q.To = gatherTime
+		}
+	}
+	q.Pad(req.duration("pre", correlation.DefaultPadding), req.duration("post", 0))
+	if from := req.timestamp("from"); !from.IsZero() {
+		q.From = from
+	}
+	if to := req.timestamp("to"); !to.IsZero() {
+		q.To = to
+	}
+	if !q.To.IsZero() && q.To.Before(q.From) {
+		return nil, badRequest("the window ends at %s, before it starts at %s", q.To.Format(time.RFC3339), q.From.Format(time.RFC3339))
+	}
+	if columns := req.str("columns"); columns != "" {
+		q.WithColumns(correlation.ColumnSets[columns]...)
+	}
+	inCluster(req, q)
+
+	if err := c.excludeNoise(q); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"dslQuery": q.KibanaURL(),
+		"kql":      q.KQL(),
+		"index":    correlation.Index,
+		"search":   q.Search(),
+		"query":    q,
+		"warnings": append([]string{}, warnings...),
+	}, nil
+}
+
+// requireStart refuses to query the logs of an object since the beginning
+// of time, the request sets the start when the object doesn't tell it
+func requireStart(req *apiRequest, data db.QueryResults, object string) error {
+	if data.StartTimestamp.IsZero() && req.timestamp("from").IsZero() {
+		return badRequest("when %s started isn't known, from must be set", object)
+	}
+	return nil
+}
+
+// inCluster restricts q to the logs of the requested cluster. The logs of
+// must-gathers whose cluster isn't known carry no cluster to match.
+func inCluster(req *apiRequest, q *correlation.Query) {
+	if cluster := req.str("cluster"); cluster != "" && cluster != db.UnknownCluster {
+		q.InCluster(cluster)
+	}
+}
+
+// excludeNoise excludes the phrases of the enabled noise filters from q
+func (c *app) excludeNoise(queries ...*correlation.Query) error {
+	filters, err := c.storeDB.ListNoiseFilters()
+	if err != nil {
+		return err
+	}
+	for _, filter := range filters {
+		if !filter.Enabled {
+			continue
+		}
+		for _, q := range queries {
+			q.Exclude(filter.Phrase)
+		}
+	}
+	return nil
+}
+
+// operatorCorrelationResponse adds the operator pods, CSVs and InstallPlans
+// the query covers to the rendered correlation query
+func (c *app) operatorCorrelationResponse(req *apiRequest, data db.QueryResults) (interface{}, error) {
+	response, err := c.correlationResponse(req, correlation.ForOperator(data), data.Warnings)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]map[string]string, 0, len(data.InvolvedOperatorPods))
+	for _, pod := range data.InvolvedOperatorPods {
+		pods = append(pods, map[string]string{"uuid": pod.UUID, "name": pod.Name, "nodeName": pod.NodeName})
+	}
+	result := response.(map[string]interface{})
+	result["pods"] = pods
+	result["csvs"] = append([]string{}, data.CSVNames...)
+	result["installPlans"] = append([]string{}, data.InstallPlanNames...)
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// UnknownCluster holds the objects of the gathers whose cluster couldn't be
+// identified, and of those imported before clusters were recorded
+const UnknownCluster = "unknown"
+
+// ErrMixedClusters is returned for a lookup that would mix the objects of
+// several clusters, a single cluster has to be picked
+var ErrMixedClusters = errors.New("the objects of several clusters can't be mixed")
+
+// InCluster returns the store scoped to a cluster: lookups only find the
+// objects of that cluster, and stored objects are recorded in it. An empty
+// cluster returns a store reading every cluster.
+func (d *DatabaseInstance) InCluster(cluster string) Store {
+	scoped := *d
+	scoped.cluster = cluster
+	return &scoped
+}
+
+// scoped restricts a query on an object table to the cluster of the store
+func (d *DatabaseInstance) scoped(query *selectQuery) *selectQuery {
+	if d.cluster != "" {
+		query.where("cluster = ?", d.cluster)
+	}
+	return query
+}
+
+// storedCluster is the cluster the objects stored are recorded in
+func (d *DatabaseInstance) storedCluster() string {
+	if d.cluster == "" {
+		return UnknownCluster
+	}
+	return d.cluster
+}
+
+// ListClusters lists the clusters of the imported must-gathers, the most
+// recently gathered first
+func (d *DatabaseInstance) ListClusters() ([]Cluster, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	// the gather times are summed up here, aggregated datetimes don't scan
+	// the same with every driver
+	query := d.scoped(newSelectQuery("select cluster, clusterName, gatherTime from importedmustgathers")).
+		order("gatherTime DESC, id DESC")
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := []Cluster{}
+	index := map[string]int{}
+	for rows.Next() {
+		var id string
+		var name sql.NullString
+		var gatherTime time.Time
+		if err := rows.Scan(&id, &name, &gatherTime); err != nil {
+			return nil, err
+		}
+		i, exists := index[id]
+		if !exists {
+			i = len(clusters)
+			index[id] = i
+			clusters = append(clusters, Cluster{ID: id, Name: name.String, LastGatherTime: gatherTime})
+		}
+		if clusters[i].Name == "" {
+			clusters[i].Name = name.String
+		}
+		clusters[i].Imports++
+		clusters[i].FirstGatherTime = gatherTime
+	}
+	return clusters, rows.Err()
+}
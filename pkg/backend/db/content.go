@@ -0,0 +1,267 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentPath is a JSONPath-like expression selecting fields of the stored
+// objects, such as {.spec.volumes[*].configMap.name}. Fields are separated
+// by dots, [n] picks an array element, [*] every element or field, and
+// ['key'] a field whose name holds dots. A field applied to an array is
+// applied to each of its elements.
+type ContentPath struct {
+	expression string
+	steps      []pathStep
+}
+
+type pathStep struct {
+	key   string
+	index int
+	// isIndex tells the step picks the array element at index
+	isIndex bool
+	// all picks every element of an array or every field of an object
+	all bool
+}
+
+// ParseContentPath parses a path expression, the surrounding braces, the
+// leading $ and the leading dot are optional
+func ParseContentPath(expression string) (*ContentPath, error) {
+	path := &ContentPath{expression: expression}
+	rest := strings.TrimSpace(expression)
+	if strings.HasPrefix(rest, "{") && strings.HasSuffix(rest, "}") {
+		rest = rest[1 : len(rest)-1]
+	}
+	rest = strings.TrimPrefix(rest, "$")
+
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty field name in path %q", expression)
+			}
+			if key == "*" {
+				path.steps = append(path.steps, pathStep{all: true})
+			} else {
+				path.steps = append(path.steps, pathStep{key: key})
+			}
+			rest = rest[end:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated [ in path %q", expression)
+			}
+			step, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%v in path %q", err, expression)
+			}
+			path.steps = append(path.steps, step)
+			rest = rest[end+1:]
+		case len(path.steps) == 0:
+			// the leading dot may be left out
+			rest = "." + rest
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %q", rest, expression)
+		}
+	}
+	if len(path.steps) == 0 {
+		return nil, fmt.Errorf("path %q selects no field", expression)
+	}
+	return path, nil
+}
+
+func parseBracket(selector string) (pathStep, error) {
+	selector = strings.TrimSpace(selector)
+	if selector == "*" {
+		return pathStep{all: true}, nil
+	}
+	if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+		return pathStep{key: selector[1 : len(selector)-1]}, nil
+	}
+	index, err := strconv.Atoi(selector)
+	if err != nil {
+		return pathStep{}, fmt.Errorf("invalid selector [%s]", selector)
+	}
+	return pathStep{index: index, isIndex: true}, nil
+}
+
+func (p *ContentPath) String() string {
+	return p.expression
+}
+
+// lastKey is the name of the last field of the path, it narrows the rows
+// read from the database
+func (p *ContentPath) lastKey() string {
+	for i := len(p.steps) - 1; i >= 0; i-- {
+		if p.steps[i].key != "" {
+			return p.steps[i].key
+		}
+	}
+	return ""
+}
+
+// Values returns the values the path selects in a JSON document, rendered
+// as strings. Missing fields and nulls are left out.
+func (p *ContentPath) Values(content []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	values := []string{}
+	for _, value := range evaluatePath(document, p.steps) {
+		if value != nil {
+			values = append(values, renderValue(value))
+		}
+	}
+	return values, nil
+}
+
+func evaluatePath(value interface{}, steps []pathStep) []interface{} {
+	if len(steps) == 0 {
+		return []interface{}{value}
+	}
+	step, rest := steps[0], steps[1:]
+	found := []interface{}{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if step.all {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				found = append(found, evaluatePath(v[key], rest)...)
+			}
+		} else if child, exists := v[step.key]; exists && !step.isIndex {
+			found = append(found, evaluatePath(child, rest)...)
+		}
+	case []interface{}:
+		switch {
+		case step.all:
+			for _, item := range v {
+				found = append(found, evaluatePath(item, rest)...)
+			}
+		case step.isIndex:
+			index := step.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index >= 0 && index < len(v) {
+				found = append(found, evaluatePath(v[index], rest)...)
+			}
+		default:
+			for _, item := range v {
+				found = append(found, evaluatePath(item, steps)...)
+			}
+		}
+	}
+	return found
+}
+
+func renderValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	rendered, _ := json.Marshal(value)
+	return string(rendered)
+}
+
+// SearchContent lists the objects of kind whose stored content contains
+// search.Text and, when search.Path is set, has a field at that path equal
+// to search.Value, or present at all when no value is given. The rows are
+// those of the object list of the kind, in the same paginated format, and
+// list the values found at the path under "matches".
+func (d *DatabaseInstance) SearchContent(kind string, search ContentSearch, page int, perPage int) (map[string]interface{}, error) {
+	spec, exists := listSpecs[kind]
+	if !exists {
+		return nil, fmt.Errorf("unknown object kind %q", kind)
+	}
+
+	extra := []string{}
+	if search.Path != nil && !contains(spec.columns, "content") {
+		extra = append(extra, "content")
+	}
+	query := d.scoped(listQuery(kind, extra...))
+	if search.Text != "" {
+		query.where("CAST(content AS CHAR) LIKE ? ESCAPE '!'", "%"+likeEscape(search.Text)+"%")
+	}
+	query.order("name ASC, " + spec.key + " ASC")
+	if search.Path == nil {
+		return d.genericGet(query, page, perPage)
+	}
+
+	// only the rows holding the field, and the value when it is stored as
+	// is, can match
+	if key := search.Path.lastKey(); key != "" {
+		query.where("CAST(content AS CHAR) LIKE ? ESCAPE '!'", `%"`+likeEscape(key)+`"%`)
+	}
+	if encoded, _ := json.Marshal(search.Value); search.Value != "" && string(encoded) == strconv.Quote(search.Value) {
+		query.where("CAST(content AS CHAR) LIKE ? ESCAPE '!'", "%"+likeEscape(search.Value)+"%")
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, 10*time.Second)
+	defer cancel()
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records, err := scanRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []map[string]interface{}{}
+	for _, record := range records {
+		content, _ := record["content"].(string)
+		values, err := search.Path.Values([]byte(content))
+		if err != nil {
+			continue
+		}
+		if (search.Value == "" && len(values) == 0) || (search.Value != "" && !contains(values, search.Value)) {
+			continue
+		}
+		if !contains(spec.columns, "content") {
+			delete(record, "content")
+		}
+		record["matches"] = values
+		data = append(data, record)
+	}
+
+	total := len(data)
+	if perPage != -1 {
+		start := (page - 1) * perPage
+		if start > total {
+			start = total
+		}
+		end := start + perPage
+		if end > total {
+			end = total
+		}
+		data = data[start:end]
+	}
+	return map[string]interface{}{
+		"data": data,
+		"meta": pageMeta(page, perPage, total),
+	}, nil
+}
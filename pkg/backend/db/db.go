@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -14,15 +13,13 @@ import (
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	"logsviewer/pkg/backend/log"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func (d *DatabaseInstance) StoreSubscription(sub *Subscription) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertSubscriptionQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertSubscriptionQuery))
 	if err != nil {
 		return err
 	}
@@ -42,6 +39,7 @@ func (d *DatabaseInstance) StoreSubscription(sub *Subscription) error {
 		sub.State,
 		madeAt,
 		sub.Content,
+		d.storedCluster(),
 	)
 	if err != nil {
 		return err
@@ -50,11 +48,101 @@ func (d *DatabaseInstance) StoreSubscription(sub *Subscription) error {
 	return nil
 }
 
+func (d *DatabaseInstance) StoreCSV(csv *ClusterServiceVersion) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertCSVQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		csv.Name,
+		csv.Namespace,
+		csv.UUID,
+		csv.Version,
+		csv.Phase,
+		csv.Replaces,
+		csv.CreationTime.Format("2006-01-02 15:04:05.999999"),
+		nullableTime(csv.LastTransitionTime),
+		csv.Content,
+		d.storedCluster(),
+	)
+	return err
+}
+
+func (d *DatabaseInstance) StoreInstallPlan(plan *InstallPlan) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertInstallPlanQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		plan.Name,
+		plan.Namespace,
+		plan.UUID,
+		plan.Phase,
+		plan.CSVNames,
+		plan.Approved,
+		plan.CreationTime.Format("2006-01-02 15:04:05.999999"),
+		nullableTime(plan.LastTransitionTime),
+		plan.Content,
+		d.storedCluster(),
+	)
+	return err
+}
+
+func (d *DatabaseInstance) StoreEvent(event *Event) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertEventQuery))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(
+		ctx,
+		event.Name,
+		event.Namespace,
+		event.UUID,
+		event.InvolvedKind,
+		event.InvolvedName,
+		event.InvolvedUID,
+		event.Type,
+		event.Reason,
+		event.Message,
+		event.Count,
+		nullableTime(event.FirstTimestamp),
+		nullableTime(event.LastTimestamp),
+		event.Content,
+		d.storedCluster(),
+	)
+	return err
+}
+
+// nullableTime stores a missing time as NULL
+func nullableTime(t metav1.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02 15:04:05.999999")
+}
+
 func (d *DatabaseInstance) StorePVC(pvc *PersistentVolumeClaim) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertPVCQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertPVCQuery))
 	if err != nil {
 		return err
 	}
@@ -74,7 +162,8 @@ func (d *DatabaseInstance) StorePVC(pvc *PersistentVolumeClaim) error {
 		pvc.VolumeMode,
 		pvc.Capacity,
 		madeAt,
-		pvc.Content)
+		pvc.Content,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -88,7 +177,7 @@ func (d *DatabaseInstance) StorePod(pod *Pod) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertPodQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertPodQuery))
 	if err != nil {
 		return err
 	}
@@ -108,7 +197,8 @@ func (d *DatabaseInstance) StorePod(pod *Pod) error {
 		madeAt,
 		pod.PVCs,
 		pod.Content,
-		pod.CreatedBy)
+		pod.CreatedBy,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -120,7 +210,7 @@ func (d *DatabaseInstance) StoreNode(node *Node) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertNodeQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertNodeQuery))
 	if err != nil {
 		return err
 	}
@@ -137,7 +227,8 @@ func (d *DatabaseInstance) StoreNode(node *Node) error {
 		node.KernelVersion,
 		node.KubletVersion,
 		node.ContainerRuntimeVersion,
-		node.Content)
+		node.Content,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -156,7 +247,7 @@ func (d *DatabaseInstance) StoreVm(vm *VirtualMachine) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertVmQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertVmQuery))
 	if err != nil {
 		return err
 	}
@@ -171,7 +262,8 @@ func (d *DatabaseInstance) StoreVm(vm *VirtualMachine) error {
 		BoolToString(vm.Created),
 		BoolToString(vm.Ready),
 		vm.Status,
-		vm.Content)
+		vm.Content,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -184,7 +276,7 @@ func (d *DatabaseInstance) StoreVmi(vmi *VirtualMachineInstance) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertVmiQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertVmiQuery))
 	if err != nil {
 		return err
 	}
@@ -199,7 +291,8 @@ func (d *DatabaseInstance) StoreVmi(vmi *VirtualMachineInstance) error {
 		vmi.Phase,
 		vmi.NodeName,
 		madeAt,
-		vmi.Content)
+		vmi.Content,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -239,7 +332,7 @@ func (d *DatabaseInstance) StoreVmiMigration(vmim *VirtualMachineInstanceMigrati
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertVmiMigrationQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertVmiMigrationQuery))
 	if err != nil {
 		return err
 	}
@@ -259,7 +352,8 @@ func (d *DatabaseInstance) StoreVmiMigration(vmim *VirtualMachineInstanceMigrati
 		vmim.TargetNode,
 		vmim.Completed,
 		vmim.Failed,
-		vmim.Content)
+		vmim.Content,
+		d.storedCluster())
 	if err != nil {
 		return err
 	}
@@ -271,64 +365,148 @@ func (d *DatabaseInstance) StoreImportedMustGather(img *ImportedMustGather) erro
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, insertImportedMustGatherQuery)
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertImportedMustGatherQuery))
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(
+	result, err := stmt.ExecContext(
 		ctx,
 		img.Name,
 		img.ImportTime.Format("2006-01-02 15:04:05.999999"),
 		img.GatherTime.Format("2006-01-02 15:04:05.999999"),
 		img.InsightsData,
+		img.Cluster,
+		img.ClusterName,
 	)
 	if err != nil {
 		return err
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	img.ID = int(id)
 	return nil
 }
 
 var (
-	insertPodQuery                = `INSERT INTO pods(keyid, kind, name, namespace, uuid, phase, activeContainers, totalContainers, nodeName, creationTime, pvcs, content, createdBy) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE keyid=VALUES(keyid);`
-	insertVmQuery                 = `INSERT INTO vms(name, namespace, uuid, running, created, ready, status, content) values (?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE uuid=VALUES(uuid);`
-	insertVmiQuery                = `INSERT INTO vmis(name, namespace, uuid, reason, phase, nodeName, creationTime, content) values (?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE uuid=VALUES(uuid);`
-	insertVmiMigrationQuery       = `INSERT INTO vmimigrations(name, namespace, uuid, phase, vmiName, targetPod, creationTime, endTimestamp, sourceNode, targetNode, completed, failed, content) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE uuid=VALUES(uuid), targetPod=VALUES(targetPod), creationTime=VALUES(creationTime), endTimestamp=VALUES(endTimestamp), sourceNode=VALUES(sourceNode), targetNode=VALUES(targetNode), completed=VALUES(completed), failed=VALUES(failed);`
-	insertNodeQuery               = `INSERT INTO nodes(name, systemUuid, status, internalIP, hostName, osImage, kernelVersion, kubletVersion, containerRuntimeVersion, content) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE name=VALUES(name);`
-	insertPVCQuery                = `INSERT INTO pvcs(name, namespace, uuid, reason, phase, accessModes, storageClassName, volumeName, volumeMode, capacity, creationTime, content) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE uuid=VALUES(uuid);`
-	insertSubscriptionQuery       = `INSERT INTO subscriptions(name, namespace, uuid, source, sourceNamespace, startingCSV, currentCSV, installedCSV, state, creationTime, content) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE uuid=VALUES(uuid);`
-	insertImportedMustGatherQuery = `INSERT INTO importedmustgathers(name, importTime, gatherTime, insightsData) values (?, ?, ?, ?);`
+	insertPodQuery = replacing(upsertQuery{
+		table:   "pods",
+		columns: []string{"keyid", "kind", "name", "namespace", "uuid", "phase", "activeContainers", "totalContainers", "nodeName", "creationTime", "pvcs", "content", "createdBy", "cluster"},
+		key:     "uuid",
+	})
+	insertVmQuery = replacing(upsertQuery{
+		table:   "vms",
+		columns: []string{"name", "namespace", "uuid", "running", "created", "ready", "status", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertVmiQuery = replacing(upsertQuery{
+		table:   "vmis",
+		columns: []string{"name", "namespace", "uuid", "reason", "phase", "nodeName", "creationTime", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertVmiMigrationQuery = replacing(upsertQuery{
+		table:   "vmimigrations",
+		columns: []string{"name", "namespace", "uuid", "phase", "vmiName", "targetPod", "creationTime", "endTimestamp", "sourceNode", "targetNode", "completed", "failed", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertNodeQuery = replacing(upsertQuery{
+		table:   "nodes",
+		columns: []string{"name", "systemUuid", "status", "internalIP", "hostName", "osImage", "kernelVersion", "kubletVersion", "containerRuntimeVersion", "content", "cluster"},
+		key:     "cluster, name",
+	})
+	insertPVCQuery = replacing(upsertQuery{
+		table:   "pvcs",
+		columns: []string{"name", "namespace", "uuid", "reason", "phase", "accessModes", "storageClassName", "volumeName", "volumeMode", "capacity", "creationTime", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertSubscriptionQuery = replacing(upsertQuery{
+		table:   "subscriptions",
+		columns: []string{"name", "namespace", "uuid", "source", "sourceNamespace", "startingCSV", "currentCSV", "installedCSV", "state", "creationTime", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertCSVQuery = replacing(upsertQuery{
+		table:   "csvs",
+		columns: []string{"name", "namespace", "uuid", "version", "phase", "replaces", "creationTime", "lastTransitionTime", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertInstallPlanQuery = replacing(upsertQuery{
+		table:   "installplans",
+		columns: []string{"name", "namespace", "uuid", "phase", "csvNames", "approved", "creationTime", "lastTransitionTime", "content", "cluster"},
+		key:     "uuid",
+	})
+	insertEventQuery = upsertQuery{
+		table:   "events",
+		columns: []string{"name", "namespace", "uuid", "involvedKind", "involvedName", "involvedUid", "type", "reason", "message", "count", "firstTimestamp", "lastTimestamp", "content", "cluster"},
+		key:     "uuid",
+		update:  []string{"count", "lastTimestamp"},
+	}
+	insertImportedMustGatherQuery = upsertQuery{
+		table:   "importedmustgathers",
+		columns: []string{"name", "importTime", "gatherTime", "insightsData", "cluster", "clusterName"},
+	}
 )
 
-var (
-	defaultUsername = "mysql"
-	defaultPassword = "supersecret"
-	//defaultHost     = "mysql"
-	defaultHost   = "0.0.0.0"
-	defaultPort   = "3306"
-	defaultdbName = "objtracker"
-)
+// replacing makes an existing row take every column of the inserted one, the
+// object store only inserts the latest snapshot of an object
+func replacing(q upsertQuery) upsertQuery {
+	key := strings.Split(q.key, ", ")
+	for _, column := range q.columns {
+		if !contains(key, column) {
+			q.update = append(q.update, column)
+		}
+	}
+	return q
+}
 
 type DatabaseInstance struct {
-	username string
-	password string
-	host     string
-	port     string
-	dbName   string
-	db       *sql.DB
-	ctx      context.Context
-	cancel   context.CancelFunc
+	dialect dialect
+	db      *sql.DB
+	ctx     context.Context
+	cancel  context.CancelFunc
+	// cluster scopes the store to the objects of a single cluster, every
+	// cluster is read when empty
+	cluster string
+	// tx runs the stores within a transaction when set
+	tx *sql.Tx
+}
+
+// inTx returns a copy of the store storing the objects within tx
+func (d *DatabaseInstance) inTx(tx *sql.Tx) *DatabaseInstance {
+	scoped := *d
+	scoped.tx = tx
+	return &scoped
+}
+
+// conn runs the statements of a store, both *sql.DB and *sql.Tx are one
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of the store if any, so that its reads see
+// the rows it wrote and don't wait for the connection it holds
+func (d *DatabaseInstance) conn() conn {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.db
+}
+
+// prepare prepares a statement within the transaction of the store, if any
+func (d *DatabaseInstance) prepare(ctx context.Context, statement string) (*sql.Stmt, error) {
+	return d.conn().PrepareContext(ctx, statement)
 }
 
-func NewDatabaseInstance() (*DatabaseInstance, error) {
+func newDatabaseInstance(dialect dialect) (*DatabaseInstance, error) {
 	dbInstance := &DatabaseInstance{
-		username: defaultUsername,
-		password: defaultPassword,
-		host:     defaultHost,
-		port:     defaultPort,
-		dbName:   defaultdbName,
+		dialect: dialect,
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	dbInstance.ctx = ctx
@@ -336,6 +514,7 @@ func NewDatabaseInstance() (*DatabaseInstance, error) {
 	err := dbInstance.connect()
 	if err != nil {
 		log.Log.Println("failed to connect to db: ", err)
+		dbInstance.Shutdown()
 		return nil, err
 	}
 
@@ -353,8 +532,12 @@ func (d *DatabaseInstance) Shutdown() (err error) {
 	return
 }
 
+func (d *DatabaseInstance) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
 func (d *DatabaseInstance) InitTables() (err error) {
-	err = d.createTables()
+	err = d.migrate()
 	if err != nil {
 		return err
 	}
@@ -363,13 +546,11 @@ func (d *DatabaseInstance) InitTables() (err error) {
 }
 
 func (d *DatabaseInstance) connect() (err error) {
-
-	uri := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", d.username, d.password, d.host, d.port, d.dbName)
-
-	db, err := sql.Open("mysql", uri)
+	db, err := sql.Open(d.dialect.driverName(), d.dialect.dataSourceName())
 	if err != nil {
 		return err
 	}
+	d.dialect.configure(db)
 
 	d.db = db
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
@@ -382,232 +563,6 @@ func (d *DatabaseInstance) connect() (err error) {
 	return nil
 }
 
-func (d *DatabaseInstance) createTables() error {
-	if err := d.createPodsTable(); err != nil {
-		return err
-	}
-	if err := d.createNodesTable(); err != nil {
-		return err
-	}
-	if err := d.createVmsTable(); err != nil {
-		return err
-	}
-	if err := d.createVmisTable(); err != nil {
-		return err
-	}
-	if err := d.createVmiMigrationsTable(); err != nil {
-		return err
-	}
-	if err := d.createPVCsTable(); err != nil {
-		return err
-	}
-	if err := d.createSubscriptionsTable(); err != nil {
-		return err
-	}
-	if err := d.createImportedMustGathersTable(); err != nil {
-		return err
-	}
-	return nil
-}
-
-func (d *DatabaseInstance) createPodsTable() error {
-
-	createPodsTable := `
-    CREATE TABLE IF NOT EXISTS pods (
-      keyid varchar(100),
-      kind varchar(100),
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      phase varchar(100),
-      activeContainers TINYINT,
-      totalContainers TINYINT,
-      nodeName varchar(100),
-      creationTime datetime,
-      pvcs varchar(200),
-      content json,
-      createdBy varchar(100),
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(createPodsTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createVmsTable() error {
-
-	vmsTableCreate := `
-    CREATE TABLE IF NOT EXISTS vms (
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      running varchar(100),
-      created varchar(100),
-      ready varchar(100),
-      status varchar(100),
-      content json,
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(vmsTableCreate)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createVmisTable() error {
-
-	vmisTableCreate := `
-    CREATE TABLE IF NOT EXISTS vmis (
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      reason varchar(100),
-      phase varchar(100),
-      nodeName varchar(100),
-      creationTime datetime,
-      content json,
-      createdBy varchar(100),
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(vmisTableCreate)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createVmiMigrationsTable() error {
-
-	vmimsTableCreate := `
-    CREATE TABLE IF NOT EXISTS vmimigrations (
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      phase varchar(100),
-      vmiName varchar(100),
-      targetPod varchar(100),
-      creationTime datetime,
-      endTimestamp datetime,
-      sourceNode varchar(100),
-      targetNode varchar(100),
-      completed BOOLEAN,
-      failed BOOLEAN,
-      content json,
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(vmimsTableCreate)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createNodesTable() error {
-
-	createNodesTable := `
-    CREATE TABLE IF NOT EXISTS nodes (
-      name varchar(100),
-      systemUuid varchar(100),
-      status varchar(100),
-      internalIP varchar(100),
-      hostName varchar(100),
-      osImage varchar(100),
-      kernelVersion varchar(100),
-      kubletVersion varchar(100),
-      containerRuntimeVersion varchar(100),
-      content json,
-      PRIMARY KEY (name)
-    );
-    `
-	err := d.execTable(createNodesTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createPVCsTable() error {
-	createPVCsTable := `
-    CREATE TABLE IF NOT EXISTS pvcs (
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      reason varchar(100),
-      phase varchar(100),
-      accessModes varchar(100),
-      storageClassName varchar(100),
-      volumeName varchar(100),
-      volumeMode varchar(100),
-      capacity varchar(100),
-      creationTime datetime,
-      content json,
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(createPVCsTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createSubscriptionsTable() error {
-	createSubscriptionsTable := `
-    CREATE TABLE IF NOT EXISTS subscriptions (
-      name varchar(100),
-      namespace varchar(100),
-      uuid varchar(100),
-      source varchar(100),
-      sourceNamespace varchar(100),
-      startingCSV varchar(100),
-      currentCSV varchar(100),
-      installedCSV varchar(100),
-      state varchar(100),
-      creationTime datetime,
-      content json,
-      PRIMARY KEY (uuid)
-    );
-    `
-	err := d.execTable(createSubscriptionsTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) createImportedMustGathersTable() error {
-	createImportedMustGathersTable := `
-    CREATE TABLE IF NOT EXISTS importedmustgathers (
-      name varchar(200),
-      importTime datetime,
-      gatherTime datetime,
-      insightsData longblob,
-      id int(16) auto_increment, 
-      PRIMARY KEY (id)
-    );
-    `
-	err := d.execTable(createImportedMustGathersTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (d *DatabaseInstance) execTable(tableSql string) error {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
@@ -624,23 +579,11 @@ func (d *DatabaseInstance) execTable(tableSql string) error {
 	}
 	return nil
 }
-func (d *DatabaseInstance) DropTables() error {
-	dropPodsTable := `
-    DROP TABLE pods;
-    `
-	err := d.execTable(dropPodsTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (d *DatabaseInstance) getMeta(page int, perPage int, queryString string) (map[string]int, error) {
+func (d *DatabaseInstance) getMeta(page int, perPage int, query *selectQuery) (map[string]int, error) {
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	stmt, err := d.db.PrepareContext(ctx, "select count(*) as totalRecords from ("+queryString+") tmp")
+	stmt, err := d.prepare(ctx, "select count(*) as totalRecords from ("+query.String()+") tmp")
 	if err != nil {
 		return nil, err
 	}
@@ -648,11 +591,16 @@ func (d *DatabaseInstance) getMeta(page int, perPage int, queryString string) (m
 
 	totalRecords := 0
 
-	err = stmt.QueryRow().Scan(&totalRecords)
+	err = stmt.QueryRowContext(ctx, query.Args()...).Scan(&totalRecords)
 	if err != nil {
 		return nil, err
 	}
 
+	return pageMeta(page, perPage, totalRecords), nil
+}
+
+// pageMeta describes the page of a list of totalRecords rows
+func pageMeta(page int, perPage int, totalRecords int) map[string]int {
 	totalPages := 0
 
 	if perPage != -1 {
@@ -665,41 +613,34 @@ func (d *DatabaseInstance) getMeta(page int, perPage int, queryString string) (m
 		totalPages++
 	}
 
-	meta := map[string]int{
+	return map[string]int{
 		"page":          page,
 		"per_page":      perPage,
 		"totalRowCount": totalRecords,
 		"totalPages":    totalPages,
 	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	return meta, nil
 }
 
 func (d *DatabaseInstance) GetResourceStats() (map[string]interface{}, error) {
-	queryString := `
--- For pods
-SELECT 'pods', phase AS status, COUNT(*) AS count FROM pods GROUP BY phase
--- For nodes
-UNION ALL
-SELECT 'nodes', status AS status, COUNT(*) AS count FROM nodes GROUP BY status
--- For vmis
-UNION ALL
-SELECT 'vmis', phase AS status, COUNT(*) AS count FROM vmis GROUP BY phase
--- For vmimigrations
-UNION ALL
-SELECT 'vmimigrations', phase AS status, COUNT(*) AS count FROM vmimigrations GROUP BY phase
--- For pvcs
-UNION ALL
-SELECT 'pvcs', phase AS status, COUNT(*) AS count FROM pvcs GROUP BY phase
-`
+	parts := []string{}
+	args := []interface{}{}
+	for _, kind := range [][2]string{
+		// table and status column
+		{"pods", "phase"},
+		{"nodes", "status"},
+		{"vmis", "phase"},
+		{"vmimigrations", "phase"},
+		{"pvcs", "phase"},
+	} {
+		query := d.scoped(newSelectQuery("SELECT '" + kind[0] + "', " + kind[1] + " AS status, COUNT(*) AS count FROM " + kind[0]))
+		parts = append(parts, query.String()+" GROUP BY "+kind[1])
+		args = append(args, query.Args()...)
+	}
+	queryString := strings.Join(parts, "\nUNION ALL\n")
 
 	log.Log.Println("queryString: ", queryString)
 
-	rows, err := d.db.Query(queryString)
+	rows, err := d.conn().Query(queryString, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -732,88 +673,61 @@ SELECT 'pvcs', phase AS status, COUNT(*) AS count FROM pvcs GROUP BY phase
 func (d *DatabaseInstance) GetMigrationQueryParams(migrationUUID string) (QueryResults, error) {
 	// looking for a source pod - pod runs on sourceNode createdBy vmiUUID before migration creationTime and after/equal vmi creation time
 
-	results := QueryResults{}
+	results := QueryResults{MigrationUUID: migrationUUID}
 	migration, err := d.getSingleMigrationByUUID(migrationUUID)
 	if err != nil {
 		return results, err
 	}
+	results.Namespace = migration.Namespace
+	results.StartTimestamp = migration.CreationTime.Time
+	results.EndTimestamp = migration.EndTimestamp.Time
+	results.TargetPod = migration.TargetPod
 
 	vmiUUID, creationTime, err := d.getVMICreationTimeByName(migration.VMIName, migration.Namespace)
-	if err != nil {
+	if err := results.missing(err, "the VMI %s/%s wasn't found, its uid, source virt-launcher and PVCs aren't matched", migration.Namespace, migration.VMIName); err != nil {
 		return results, err
 	}
+	results.VMIUUID = vmiUUID
 
-	targetPodUUID, err := d.getPodUUIDByName(migration.TargetPod, migration.Namespace)
-	if err != nil {
+	results.TargetPodUUID, err = d.getPodUUIDByName(migration.TargetPod, migration.Namespace)
+	if err := results.missing(err, "the target virt-launcher %s/%s wasn't found, only its name is matched", migration.Namespace, migration.TargetPod); err != nil {
 		return results, err
 	}
-	timeLayout := "2006-01-02 15:04:05.999999"
-	vmiMadeAt := creationTime.Format(timeLayout)
-	migrationMadeAt := migration.CreationTime.Format(timeLayout)
-	migrationEndedAt := migration.EndTimestamp.Format(timeLayout)
-
-	sourcePodQueryString := fmt.Sprintf("select uuid, name from pods where createdBy='%s' AND nodeName='%s' AND creationTime BETWEEN '%s' and '%s' ORDER BY creationTime ASC LIMIT 1", vmiUUID, migration.SourceNode, vmiMadeAt, migrationMadeAt)
-	virtHandlerQueryString := "select name from pods where nodeName='%s' AND name like 'virt-handler%%'"
-
-	results.StartTimestamp, _ = time.Parse(timeLayout, migrationMadeAt)
-	results.EndTimestamp, _ = time.Parse(timeLayout, migrationEndedAt)
-	results.TargetPod = migration.TargetPod
-	results.TargetPodUUID = targetPodUUID
-	results.MigrationUUID = migrationUUID
-	results.VMIUUID = vmiUUID
 
 	// get source virt-launcher info
-	rows := d.db.QueryRow(sourcePodQueryString)
-	err = rows.Scan(&results.SourcePodUUID, &results.SourcePod)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Log.Println("migration source pod lookup - can't find anything with this uuid: ", vmiUUID)
-			return results, err
-		} else {
+	if vmiUUID != "" {
+		timeLayout := "2006-01-02 15:04:05.999999"
+		sourcePodQuery := d.scoped(newSelectQuery("select uuid, name from pods")).
+			where("createdBy = ?", vmiUUID).
+			where("nodeName = ?", migration.SourceNode).
+			where("creationTime BETWEEN ? AND ?", creationTime.Format(timeLayout), migration.CreationTime.Format(timeLayout)).
+			order("creationTime ASC")
+		err = d.queryRow(sourcePodQuery).Scan(&results.SourcePodUUID, &results.SourcePod)
+		if err := results.missing(err, "no virt-launcher of the VMI was found on the source node %s", migration.SourceNode); err != nil {
 			log.Log.Println("migration source pod lookup, ERROR: ", err, " for uuid: ", vmiUUID)
 			return results, err
 		}
 	}
 
 	// get the source virt-handler
-	rows = d.db.QueryRow(fmt.Sprintf(virtHandlerQueryString, migration.SourceNode))
-	err = rows.Scan(&results.SourceHandler)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Log.Println("migration src virt-handler lookup -  can't find virt-handler on node: ", migration.SourceNode)
-			return results, err
-		} else {
-			log.Log.Println("migration src virt-handler lookup - ERROR: ", err, " for nodeName: ", migration.SourceNode)
-			return results, err
-		}
+	err = d.queryRow(d.virtHandlerQuery(migration.SourceNode)).Scan(&results.SourceHandler)
+	if err := results.missing(err, "no virt-handler was found on the source node %s", migration.SourceNode); err != nil {
+		log.Log.Println("migration src virt-handler lookup - ERROR: ", err, " for nodeName: ", migration.SourceNode)
+		return results, err
 	}
 
 	// get the target virt-handler
-	rows = d.db.QueryRow(fmt.Sprintf(virtHandlerQueryString, migration.TargetNode))
-	err = rows.Scan(&results.TargetHandler)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Log.Println("migration target virt-handler lookup -  can't find virt-handler on node: ", migration.TargetNode)
-			return results, err
-		} else {
-			log.Log.Println("migration target virt-handler lookup - ERROR: ", err, " for nodeName: ", migration.TargetNode)
-			return results, err
-		}
-	}
-
-	// get a list of PVCs
-	pvcsListRes, err := d.GetVMIPVCs(vmiUUID)
-	if err != nil {
+	err = d.queryRow(d.virtHandlerQuery(migration.TargetNode)).Scan(&results.TargetHandler)
+	if err := results.missing(err, "no virt-handler was found on the target node %s", migration.TargetNode); err != nil {
+		log.Log.Println("migration target virt-handler lookup - ERROR: ", err, " for nodeName: ", migration.TargetNode)
 		return results, err
 	}
-	data, ok := pvcsListRes["data"].([]map[string]interface{})
-	if !ok {
-		log.Log.Println("failed to covert pvc data")
-		return results, nil
-	}
 
-	for _, pvc := range data {
-		results.PVCs = append(results.PVCs, fmt.Sprintf("%v", pvc["uuid"]))
+	if vmiUUID != "" {
+		results.PVCs, err = d.vmiPVCUUIDs(vmiUUID)
+		if err != nil {
+			return results, err
+		}
 	}
 
 	return results, nil
@@ -821,13 +735,13 @@ func (d *DatabaseInstance) GetMigrationQueryParams(migrationUUID string) (QueryR
 
 func (d *DatabaseInstance) GetFullVMIHistoryQueryParams(vmiUUID string) (QueryResults, error) {
 	results := QueryResults{VMIUUID: vmiUUID}
-	vmiQueryString := fmt.Sprintf("select creationTime from vmis where uuid = '%s'", vmiUUID)
-	sourcePodQueryString := fmt.Sprintf("select uuid, name, nodeName from pods where createdBy='%s'", vmiUUID)
-	virtHandlerQueryString := "select name from pods where nodeName IN (%s) AND name like 'virt-handler%%'"
+	vmiQuery := d.scoped(newSelectQuery("select creationTime, content from vmis")).where("uuid = ?", vmiUUID)
+	sourcePodQuery := d.scoped(newSelectQuery("select uuid, name, nodeName from pods")).where("createdBy = ?", vmiUUID)
 
 	// get VMIs creation time
-	rows := d.db.QueryRow(vmiQueryString)
-	if err := rows.Scan(&results.StartTimestamp); err != nil {
+	var content json.RawMessage
+	rows := d.queryRow(vmiQuery)
+	if err := rows.Scan(&results.StartTimestamp, &content); err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("getVMIQueryParams can't find any VMIs with this uuid: ", vmiUUID)
 			return results, err
@@ -836,9 +750,10 @@ func (d *DatabaseInstance) GetFullVMIHistoryQueryParams(vmiUUID string) (QueryRe
 			return results, err
 		}
 	}
+	results.EndTimestamp = vmiEndTime(content)
 
 	// get all involved virt-launchers
-	podsResultsMap, err := d.genericGet(sourcePodQueryString, -1, -1)
+	podsResultsMap, err := d.genericGet(sourcePodQuery, -1, -1)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("failed to get pods data for vmi uuid: ", vmiUUID)
@@ -866,11 +781,12 @@ func (d *DatabaseInstance) GetFullVMIHistoryQueryParams(vmiUUID string) (QueryRe
 		return results, nil
 	}
 
-	nodesByte := []byte(fmt.Sprintf(`'%s'`, strings.Join(nodesList, `', '`)))
-	virtHandlerQueryString = fmt.Sprintf(virtHandlerQueryString, string(nodesByte))
+	virtHandlersQuery := d.scoped(newSelectQuery("select uuid, name, nodeName from pods")).
+		whereIn("nodeName", nodesList).
+		where("name like ?", "virt-handler%")
 
 	// get all involved virt-handlers
-	handlersResultsMap, err := d.genericGet(virtHandlerQueryString, -1, -1)
+	handlersResultsMap, err := d.genericGet(virtHandlersQuery, -1, -1)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("failed to get virt-handler pods data for vmi uuid: ", vmiUUID)
@@ -917,62 +833,280 @@ func (d *DatabaseInstance) GetFullVMIHistoryQueryParams(vmiUUID string) (QueryRe
 func (d *DatabaseInstance) GetVMIQueryParams(vmiUUID string, nodeName string) (QueryResults, error) {
 	results := QueryResults{VMIUUID: vmiUUID}
 
-	sourcePodQueryString := fmt.Sprintf("select uuid, name, namespace, creationTime from pods where createdBy='%s' AND nodeName='%s'", vmiUUID, nodeName)
-	virtHandlerQueryString := fmt.Sprintf("select name from pods where nodeName='%s' AND name like 'virt-handler%%'", nodeName)
+	sourcePodQuery := d.scoped(newSelectQuery("select uuid, name, namespace, creationTime, content from pods")).
+		where("createdBy = ?", vmiUUID).
+		where("nodeName = ?", nodeName)
 
 	// get source virt-launcher info
-	rows := d.db.QueryRow(sourcePodQueryString)
-	err := rows.Scan(&results.SourcePodUUID, &results.SourcePod, &results.Namespace, &results.StartTimestamp)
-	if err != nil {
-		if err == sql.ErrNoRows {
+	var content json.RawMessage
+	rows := d.queryRow(sourcePodQuery)
+	err := rows.Scan(&results.SourcePodUUID, &results.SourcePod, &results.Namespace, &results.StartTimestamp, &content)
+	if err == nil {
+		// the VMI ran on this node as long as its launcher pod did
+		results.EndTimestamp = podEndTime(content)
+	} else if err == sql.ErrNoRows {
+		// without its launcher the VMI itself bounds the window
+		err = d.queryRow(d.scoped(newSelectQuery("select namespace, creationTime, content from vmis")).where("uuid = ?", vmiUUID)).
+			Scan(&results.Namespace, &results.StartTimestamp, &content)
+		if err != nil {
 			log.Log.Println("getVMIQueryParams can't find anything with this uuid: ", vmiUUID)
 			return results, err
-		} else {
-			log.Log.Println("getVMIQueryParams ERROR: ", err, " for uuid: ", vmiUUID)
-			return results, err
 		}
+		results.EndTimestamp = vmiEndTime(content)
+		results.warn("no virt-launcher of the VMI was found on node %s, the window spans the whole VMI lifetime", nodeName)
+	} else {
+		log.Log.Println("getVMIQueryParams ERROR: ", err, " for uuid: ", vmiUUID)
+		return results, err
 	}
 
 	// get the relevant virt-handler
-	rows = d.db.QueryRow(virtHandlerQueryString)
-	err = rows.Scan(&results.SourceHandler)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			log.Log.Println("getVMIQueryParams can't find virt-handler on node: ", nodeName)
-			return results, err
-		} else {
-			log.Log.Println("getVMIQueryParams ERROR: ", err, " for nodeName: ", nodeName)
-			return results, err
-		}
+	err = d.queryRow(d.virtHandlerQuery(nodeName)).Scan(&results.SourceHandler)
+	if err := results.missing(err, "no virt-handler was found on node %s", nodeName); err != nil {
+		log.Log.Println("getVMIQueryParams ERROR: ", err, " for nodeName: ", nodeName)
+		return results, err
 	}
 
-	// get a list of PVCs
-	pvcsListRes, err := d.GetVMIPVCs(vmiUUID)
+	results.PVCs, err = d.vmiPVCUUIDs(vmiUUID)
 	if err != nil {
 		return results, err
 	}
 
+	return results, nil
+}
+
+// warn records a component the query is built without
+func (r *QueryResults) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	log.Log.Println("correlation lookup:", warning)
+	r.Warnings = append(r.Warnings, warning)
+}
+
+// missing turns a lookup that found nothing into a warning, any other
+// error is returned as is
+func (r *QueryResults) missing(err error, format string, args ...interface{}) error {
+	if err == sql.ErrNoRows {
+		r.warn(format, args...)
+		return nil
+	}
+	return err
+}
+
+// vmiPVCUUIDs lists the uids of the claims used by a VMI
+func (d *DatabaseInstance) vmiPVCUUIDs(vmiUUID string) ([]string, error) {
+	pvcsListRes, err := d.GetVMIPVCs(vmiUUID)
+	if err != nil {
+		return nil, err
+	}
 	data, ok := pvcsListRes["data"].([]map[string]interface{})
 	if !ok {
 		log.Log.Println("failed to covert pvc data")
-		return results, nil
+		return nil, nil
 	}
 
+	pvcs := []string{}
 	for _, pvc := range data {
-		results.PVCs = append(results.PVCs, fmt.Sprintf("%v", pvc["uuid"]))
+		pvcs = append(pvcs, fmt.Sprintf("%v", pvc["uuid"]))
+	}
+	return pvcs, nil
+}
+
+// GetNodeQueryParams collects the virt-handler and virt-launchers scheduled on
+// a node. The window starts at the last transition of its Ready condition.
+func (d *DatabaseInstance) GetNodeQueryParams(nodeName string) (QueryResults, error) {
+	results := QueryResults{NodeName: nodeName}
+
+	// node names are only unique within a cluster
+	var clusters []string
+	query := d.scoped(newSelectQuery("select cluster from nodes")).where("name = ?", nodeName)
+	rows, err := d.conn().Query(query.String(), query.Args()...)
+	if err != nil {
+		log.Log.Println("getNodeQueryParams ERROR: ", err, " for node: ", nodeName)
+		return results, err
+	}
+	for rows.Next() {
+		var cluster string
+		if err := rows.Scan(&cluster); err != nil {
+			rows.Close()
+			return results, err
+		}
+		clusters = append(clusters, cluster)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return results, err
+	}
+	switch len(clusters) {
+	case 0:
+		log.Log.Println("getNodeQueryParams can't find a node named: ", nodeName)
+		return results, sql.ErrNoRows
+	case 1:
+		// the pods are those of the cluster of the node
+		d = d.InCluster(clusters[0]).(*DatabaseInstance)
+	default:
+		return results, fmt.Errorf("%w, nodes named %s are found in the clusters %s", ErrMixedClusters, nodeName, strings.Join(clusters, ", "))
+	}
+
+	var content json.RawMessage
+	if err := d.queryRow(d.scoped(newSelectQuery("select content from nodes")).where("name = ?", nodeName)).Scan(&content); err != nil {
+		log.Log.Println("getNodeQueryParams ERROR: ", err, " for node: ", nodeName)
+		return results, err
+	}
+	results.StartTimestamp = nodeReadyTransitionTime(content)
+
+	podsQuery := d.scoped(newSelectQuery("select uuid, name, nodeName from pods")).
+		where("nodeName = ?", nodeName).
+		where("name like ? or name like ?", "virt-handler%", "virt-launcher%").
+		order("creationTime ASC")
+	podsResultsMap, err := d.genericGet(podsQuery, -1, -1)
+	if err != nil {
+		log.Log.Println("failed to get pods data ERROR: ", err, " for node: ", nodeName)
+		return results, err
+	}
+
+	if data, ok := podsResultsMap["data"].([]map[string]interface{}); ok {
+		for _, pod := range data {
+			newPod := Pod{
+				UUID:     fmt.Sprintf("%v", pod["uuid"]),
+				Name:     fmt.Sprintf("%v", pod["name"]),
+				NodeName: fmt.Sprintf("%v", pod["nodeName"]),
+			}
+			if strings.HasPrefix(newPod.Name, "virt-handler") {
+				results.InvolvedVirtHandlers = append(results.InvolvedVirtHandlers, newPod)
+			} else {
+				results.InvolvedVirtLaunchers = append(results.InvolvedVirtLaunchers, newPod)
+			}
+		}
+	} else {
+		log.Log.Println("failed to covert node pods data")
+	}
+
+	if results.StartTimestamp.IsZero() {
+		// without a Ready transition nor a creation, the node is known to
+		// run workloads since its first virt-handler or virt-launcher
+		results.StartTimestamp, err = d.earliestCreation(d.scoped(newSelectQuery("select creationTime from pods")).
+			where("nodeName = ?", nodeName).
+			where("name like ? or name like ?", "virt-handler%", "virt-launcher%"))
+		if err != nil {
+			log.Log.Println("failed to get the first pod creation ERROR: ", err, " for node: ", nodeName)
+			return results, err
+		}
 	}
 
 	return results, nil
 }
 
+// GetPVCQueryParams collects the pods involved in provisioning and using a
+// claim: the CDI importer, uploader and clone pods, the CSI controller and
+// node plugin pods, and the virt-launchers mounting it.
+func (d *DatabaseInstance) GetPVCQueryParams(pvcUUID string) (QueryResults, error) {
+	results := QueryResults{PVCs: []string{pvcUUID}}
+
+	var content json.RawMessage
+	rows := d.queryRow(d.scoped(newSelectQuery("select name, namespace, creationTime, content from pvcs")).where("uuid = ?", pvcUUID))
+	if err := rows.Scan(&results.PVCName, &results.Namespace, &results.StartTimestamp, &content); err != nil {
+		if err == sql.ErrNoRows {
+			log.Log.Println("getPVCQueryParams can't find anything with this uuid: ", pvcUUID)
+		} else {
+			log.Log.Println("getPVCQueryParams ERROR: ", err, " for uuid: ", pvcUUID)
+		}
+		return results, err
+	}
+
+	var pvc k8sv1.PersistentVolumeClaim
+	if err := json.Unmarshal(content, &pvc); err != nil {
+		return results, err
+	}
+	if pvc.DeletionTimestamp != nil {
+		results.EndTimestamp = pvc.DeletionTimestamp.Time
+	}
+	for _, owner := range pvc.OwnerReferences {
+		if owner.Kind == "DataVolume" {
+			results.DataVolumeUUID = string(owner.UID)
+		}
+	}
+
+	// pods mounting the claim, pods.pvcs is a comma separated list of claim names
+	mountingPods, err := d.queryPods(d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods")).
+		where("namespace = ?", results.Namespace).
+		where("pvcs like ?", "%"+results.PVCName+"%"))
+	if err != nil {
+		return results, err
+	}
+	nodes := []string{}
+	if node := pvc.Annotations["volume.kubernetes.io/selected-node"]; node != "" {
+		nodes = append(nodes, node)
+	}
+	for _, pod := range mountingPods {
+		if !contains(strings.Split(pod.PVCs, ","), results.PVCName) {
+			continue
+		}
+		if strings.HasPrefix(pod.Name, "virt-launcher") {
+			results.InvolvedVirtLaunchers = append(results.InvolvedVirtLaunchers, pod)
+		}
+		if pod.NodeName != "" && !contains(nodes, pod.NodeName) {
+			nodes = append(nodes, pod.NodeName)
+		}
+	}
+
+	// CDI names its worker pods after the claim, clone source pods after its uid
+	results.InvolvedCDIPods, err = d.queryPods(d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods")).
+		where("(namespace = ? and (name like ? or name like ? or name like ?)) or name like ?",
+			results.Namespace, "importer-"+results.PVCName+"%", "cdi-upload-"+results.PVCName+"%", "cdi-clone-%"+results.PVCName+"%",
+			"%"+pvcUUID+"%"))
+	if err != nil {
+		return results, err
+	}
+
+	// the CSI controllers and the node plugins where the claim was used
+	csiQuery := d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods")).where("name like ?", "%csi%")
+	if len(nodes) > 0 {
+		csiQuery.where("name like ? or name like ? or nodeName IN ("+placeholders(len(nodes))+")",
+			append([]interface{}{"%provisioner%", "%controller%"}, toArgs(nodes)...)...)
+	} else {
+		csiQuery.where("name like ? or name like ?", "%provisioner%", "%controller%")
+	}
+	results.InvolvedCSIPods, err = d.queryPods(csiQuery)
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// queryPods runs a query selecting uuid, name, nodeName and pvcs from pods
+func (d *DatabaseInstance) queryPods(query *selectQuery) ([]Pod, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pods := []Pod{}
+	for rows.Next() {
+		pod := Pod{}
+		var nodeName, pvcs sql.NullString
+		if err := rows.Scan(&pod.UUID, &pod.Name, &nodeName, &pvcs); err != nil {
+			return nil, err
+		}
+		pod.NodeName = nodeName.String
+		pod.PVCs = pvcs.String
+		pods = append(pods, pod)
+	}
+	return pods, rows.Err()
+}
+
 func (d *DatabaseInstance) GetPodQueryParams(podUUID string) (QueryResults, error) {
 	results := QueryResults{}
 
-	sourcePodQueryString := fmt.Sprintf("select uuid, name, namespace, creationTime from pods where uuid='%s'", podUUID)
+	sourcePodQuery := d.scoped(newSelectQuery("select uuid, name, namespace, creationTime, content from pods")).where("uuid = ?", podUUID)
 
 	// get source virt-launcher info
-	rows := d.db.QueryRow(sourcePodQueryString)
-	err := rows.Scan(&results.SourcePodUUID, &results.SourcePod, &results.Namespace, &results.StartTimestamp)
+	var content json.RawMessage
+	rows := d.queryRow(sourcePodQuery)
+	err := rows.Scan(&results.SourcePodUUID, &results.SourcePod, &results.Namespace, &results.StartTimestamp, &content)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("getPodQueryParams can't find anything with this uuid: ", podUUID)
@@ -982,6 +1116,7 @@ func (d *DatabaseInstance) GetPodQueryParams(podUUID string) (QueryResults, erro
 			return results, err
 		}
 	}
+	results.EndTimestamp = podEndTime(content)
 
 	// get a list of PVCs
 	pvcsListRes, err := d.GetPodPVCs(podUUID)
@@ -1002,40 +1137,32 @@ func (d *DatabaseInstance) GetPodQueryParams(podUUID string) (QueryResults, erro
 }
 
 func (d *DatabaseInstance) GetPods(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
-	queryString := "select uuid, name, namespace, phase, activeContainers, totalContainers, creationTime, createdBy from pods"
+	query := d.scoped(listQuery("pods"))
 	if queryDetails != nil {
-		conditions := []string{}
-		if *queryDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
-		}
-
 		if queryDetails.Name != "" {
-			conditions = append(conditions, fmt.Sprintf("name='%s'", queryDetails.Name))
+			query.where("name = ?", queryDetails.Name)
 		}
 		if queryDetails.Namespace != "" {
-			conditions = append(conditions, fmt.Sprintf("namespace='%s'", queryDetails.Namespace))
+			query.where("namespace = ?", queryDetails.Namespace)
 		}
 		if queryDetails.UUID != "" {
-			conditions = append(conditions, fmt.Sprintf("uuid='%s'", queryDetails.UUID))
+			query.where("uuid = ?", queryDetails.UUID)
 		}
 		if queryDetails.Status != "" {
 			if queryDetails.Status == "healthy" {
 				// Running or Succeeded
-				conditions = append(conditions, fmt.Sprintf("phase='Running' OR phase='Succeeded'"))
+				query.whereIn("phase", []string{"Running", "Succeeded"})
 			} else if queryDetails.Status == "unhealthy" {
 				// Failed
-				conditions = append(conditions, fmt.Sprintf("phase='Failed'"))
+				query.where("phase = ?", "Failed")
 			} else if queryDetails.Status == "warning" {
 				// other thank Running, Succeeded, Failed
-				conditions = append(conditions, fmt.Sprintf("phase!='Running' AND phase!='Succeeded' AND phase!='Failed'"))
+				query.whereNotIn("phase", []string{"Running", "Succeeded", "Failed"})
 			}
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	log.Log.Println("queryString: ", queryString)
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("pods", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1045,9 +1172,9 @@ func (d *DatabaseInstance) GetPods(page int, perPage int, queryDetails *GenericQ
 func (d *DatabaseInstance) GetNodeObject(nodeUUID string) (*k8sv1.Node, error) {
 	var content json.RawMessage
 
-	queryString := fmt.Sprintf("select content from nodes where systemUuid = '%s'", nodeUUID)
+	query := d.scoped(newSelectQuery("select content from nodes")).where("systemUuid = ?", nodeUUID)
 
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1076,9 +1203,9 @@ func (d *DatabaseInstance) GetNodeObject(nodeUUID string) (*k8sv1.Node, error) {
 func (d *DatabaseInstance) GetPVCObject(pvcUUID string) (*k8sv1.PersistentVolumeClaim, error) {
 	var content json.RawMessage
 
-	queryString := fmt.Sprintf("select content from pvcs where uuid = '%s'", pvcUUID)
+	query := d.scoped(newSelectQuery("select content from pvcs")).where("uuid = ?", pvcUUID)
 
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1104,54 +1231,33 @@ func (d *DatabaseInstance) GetPVCObject(pvcUUID string) (*k8sv1.PersistentVolume
 }
 
 func (d *DatabaseInstance) GetPVCs(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
-	queryString := "select name, namespace, uuid, reason, phase, accessModes, storageClassName, volumeName, volumeMode, capacity, creationTime from pvcs"
+	query := d.scoped(listQuery("pvcs"))
 	if queryDetails != nil {
-		conditions := []string{}
-		if *queryDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
-		}
-
 		if queryDetails.Name != "" {
 			// handle list of claim names
-			claimsList := strings.Split(queryDetails.Name, ",")
-			if len(claimsList) > 1 {
-				queryStr := "name in ("
-				for idx, claim := range claimsList {
-					queryStr += fmt.Sprintf("'%s'", claim)
-					if idx != len(claimsList)-1 {
-						queryStr += ", "
-					}
-				}
-				queryStr += ")"
-				conditions = append(conditions, queryStr)
-			} else {
-				conditions = append(conditions, fmt.Sprintf("name='%s'", queryDetails.Name))
-			}
+			query.whereIn("name", strings.Split(queryDetails.Name, ","))
 		}
 		if queryDetails.Namespace != "" {
-			conditions = append(conditions, fmt.Sprintf("namespace='%s'", queryDetails.Namespace))
+			query.where("namespace = ?", queryDetails.Namespace)
 		}
 		if queryDetails.UUID != "" {
-			conditions = append(conditions, fmt.Sprintf("uuid='%s'", queryDetails.UUID))
+			query.where("uuid = ?", queryDetails.UUID)
 		}
 		if queryDetails.Status != "" {
 			if queryDetails.Status == "healthy" {
 				// Bound
-				conditions = append(conditions, fmt.Sprintf("phase='Bound'"))
+				query.where("phase = ?", "Bound")
 			} else if queryDetails.Status == "unhealthy" {
 				// Lost
-				conditions = append(conditions, fmt.Sprintf("phase='Lost'"))
+				query.where("phase = ?", "Lost")
 			} else if queryDetails.Status == "warning" {
 				// other thank Bound, Lost
-				conditions = append(conditions, fmt.Sprintf("phase!='Bound' AND phase!='Lost'"))
+				query.whereNotIn("phase", []string{"Bound", "Lost"})
 			}
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	log.Log.Println("queryString: ", queryString)
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("pvcs", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1159,8 +1265,8 @@ func (d *DatabaseInstance) GetPVCs(page int, perPage int, queryDetails *GenericQ
 }
 
 func (d *DatabaseInstance) GetVMIPVCs(vmiUUID string) (map[string]interface{}, error) {
-	podQueryString := fmt.Sprintf("select pvcs from pods where createdBy='%s'", vmiUUID)
-	res, err := d.genericGetPVCs(podQueryString)
+	podQuery := d.scoped(newSelectQuery("select pvcs from pods")).where("createdBy = ?", vmiUUID)
+	res, err := d.genericGetPVCs(podQuery)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("GeVMIPVCs can't find anything with this uuid: ", vmiUUID)
@@ -1174,8 +1280,8 @@ func (d *DatabaseInstance) GetVMIPVCs(vmiUUID string) (map[string]interface{}, e
 }
 
 func (d *DatabaseInstance) GetPodPVCs(podUUID string) (map[string]interface{}, error) {
-	queryString := fmt.Sprintf("select pvcs from pods where uuid = '%s'", podUUID)
-	res, err := d.genericGetPVCs(queryString)
+	query := d.scoped(newSelectQuery("select pvcs from pods")).where("uuid = ?", podUUID)
+	res, err := d.genericGetPVCs(query)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Log.Println("GetPodPVCs can't find anything with this uuid: ", podUUID)
@@ -1188,12 +1294,12 @@ func (d *DatabaseInstance) GetPodPVCs(podUUID string) (map[string]interface{}, e
 	return res, nil
 }
 
-func (d *DatabaseInstance) genericGetPVCs(queryString string) (map[string]interface{}, error) {
+func (d *DatabaseInstance) genericGetPVCs(query *selectQuery) (map[string]interface{}, error) {
 	podPvcs := ""
 	pvcsList := make(map[string]interface{})
 
 	// get pod pvcs
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&podPvcs)
 	if err != nil {
 		return nil, err
@@ -1223,9 +1329,9 @@ func (d *DatabaseInstance) genericGetPVCs(queryString string) (map[string]interf
 func (d *DatabaseInstance) GetVMObject(vmUUID string) (*kubevirtv1.VirtualMachine, error) {
 	var content json.RawMessage
 
-	queryString := fmt.Sprintf("select content from vms where uuid = '%s'", vmUUID)
+	query := d.scoped(newSelectQuery("select content from vms")).where("uuid = ?", vmUUID)
 
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1252,9 +1358,9 @@ func (d *DatabaseInstance) GetVMObject(vmUUID string) (*kubevirtv1.VirtualMachin
 func (d *DatabaseInstance) GetVMIObject(vmiUUID string) (*kubevirtv1.VirtualMachineInstance, error) {
 	var content json.RawMessage
 
-	queryString := fmt.Sprintf("select content from vmis where uuid = '%s'", vmiUUID)
+	query := d.scoped(newSelectQuery("select content from vmis")).where("uuid = ?", vmiUUID)
 
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1282,10 +1388,10 @@ func (d *DatabaseInstance) GetVMIObject(vmiUUID string) (*kubevirtv1.VirtualMach
 func (d *DatabaseInstance) GetPodObject(podUUID string) (*k8sv1.Pod, error) {
 	var content json.RawMessage
 
-	queryString := fmt.Sprintf("select content from pods where uuid = '%s'", podUUID)
+	query := d.scoped(newSelectQuery("select content from pods")).where("uuid = ?", podUUID)
 
 	// get source virt-launcher info
-	rows := d.db.QueryRow(queryString)
+	rows := d.queryRow(query)
 	err := rows.Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1311,35 +1417,25 @@ func (d *DatabaseInstance) GetPodObject(podUUID string) (*k8sv1.Pod, error) {
 }
 
 func (d *DatabaseInstance) GetNodes(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
-	queryString := "select name, systemUuid, status, internalIP, hostName, osImage, kernelVersion, kubletVersion, containerRuntimeVersion from nodes"
+	query := d.scoped(listQuery("nodes"))
 
 	if queryDetails != nil {
-		conditions := []string{}
-		if *queryDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
-		}
-
 		if queryDetails.Name != "" {
-			conditions = append(conditions, fmt.Sprintf("name='%s'", queryDetails.Name))
-		}
-		if queryDetails.Namespace != "" {
-			conditions = append(conditions, fmt.Sprintf("namespace='%s'", queryDetails.Namespace))
+			query.where("name = ?", queryDetails.Name)
 		}
 		if queryDetails.UUID != "" {
-			conditions = append(conditions, fmt.Sprintf("uuid='%s'", queryDetails.UUID))
+			query.where("systemUuid = ?", queryDetails.UUID)
 		}
 		if queryDetails.Status != "" {
 			if queryDetails.Status == "healthy" {
-				conditions = append(conditions, fmt.Sprintf("status='%s'", "Ready"))
+				query.where("status = ?", "Ready")
 			} else if queryDetails.Status == "unhealthy" {
-				conditions = append(conditions, fmt.Sprintf("status='%s'", "NotReady"))
+				query.where("status = ?", "NotReady")
 			}
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("nodes", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1347,18 +1443,21 @@ func (d *DatabaseInstance) GetNodes(page int, perPage int, queryDetails *Generic
 }
 
 func (d *DatabaseInstance) GetVms(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
-	queryString := "select uuid, name, namespace, running, created, ready, status from vms"
+	query := d.scoped(listQuery("vms"))
 
 	if queryDetails != nil {
-		conditions := []string{}
-		if *queryDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
+		if queryDetails.Name != "" {
+			query.where("name = ?", queryDetails.Name)
+		}
+		if queryDetails.Namespace != "" {
+			query.where("namespace = ?", queryDetails.Namespace)
+		}
+		if queryDetails.UUID != "" {
+			query.where("uuid = ?", queryDetails.UUID)
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("vms", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1366,31 +1465,33 @@ func (d *DatabaseInstance) GetVms(page int, perPage int, queryDetails *GenericQu
 }
 
 func (d *DatabaseInstance) GetVmis(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
-	queryString := "select uuid, name, namespace, phase, reason, nodeName, creationTime from vmis"
+	query := d.scoped(listQuery("vmis"))
 
 	if queryDetails != nil {
-		conditions := []string{}
-		if *queryDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
+		if queryDetails.Name != "" {
+			query.where("name = ?", queryDetails.Name)
+		}
+		if queryDetails.Namespace != "" {
+			query.where("namespace = ?", queryDetails.Namespace)
+		}
+		if queryDetails.UUID != "" {
+			query.where("uuid = ?", queryDetails.UUID)
 		}
-
 		if queryDetails.Status != "" {
 			if queryDetails.Status == "healthy" {
 				// Running or Succeeded
-				conditions = append(conditions, fmt.Sprintf("phase='%s' OR phase='%s'", "Running", "Succeeded"))
+				query.whereIn("phase", []string{"Running", "Succeeded"})
 			} else if queryDetails.Status == "unhealthy" {
 				// Failed
-				conditions = append(conditions, fmt.Sprintf("phase='%s'", "Failed"))
+				query.where("phase = ?", "Failed")
 			} else if queryDetails.Status == "warning" {
 				// Other than Running, Succeeded, Failed
-				conditions = append(conditions, fmt.Sprintf("phase!='%s' AND phase!='%s' AND phase!='%s'", "Running", "Succeeded", "Failed"))
+				query.whereNotIn("phase", []string{"Running", "Succeeded", "Failed"})
 			}
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("vmis", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1399,41 +1500,33 @@ func (d *DatabaseInstance) GetVmis(page int, perPage int, queryDetails *GenericQ
 
 func (d *DatabaseInstance) GetVmiMigrations(page int, perPage int, vmiDetails *GenericQueryDetails) (map[string]interface{}, error) {
 
-	queryString := "select name, namespace, uuid, phase, vmiName, targetPod, creationTime, endTimestamp, sourceNode, targetNode, completed, failed from vmimigrations"
+	query := d.scoped(listQuery("migrations"))
 
 	if vmiDetails != nil {
-		conditions := []string{}
-		if *vmiDetails != (GenericQueryDetails{}) {
-			queryString = fmt.Sprintf("%s where ", queryString)
-		}
-
 		if vmiDetails.Name != "" {
-			conditions = append(conditions, fmt.Sprintf("vmiName='%s'", vmiDetails.Name))
+			query.where("vmiName = ?", vmiDetails.Name)
 		}
 		if vmiDetails.Namespace != "" {
-			conditions = append(conditions, fmt.Sprintf("namespace='%s'", vmiDetails.Namespace))
+			query.where("namespace = ?", vmiDetails.Namespace)
 		}
 		if vmiDetails.UUID != "" {
-			conditions = append(conditions, fmt.Sprintf("uuid='%s'", vmiDetails.UUID))
+			query.where("uuid = ?", vmiDetails.UUID)
 		}
 		if vmiDetails.Status != "" {
 			if vmiDetails.Status == "healthy" {
 				// Running or Succeeded
-				conditions = append(conditions, fmt.Sprintf("phase='%s' OR phase='%s'", "Running", "Succeeded"))
+				query.whereIn("phase", []string{"Running", "Succeeded"})
 			} else if vmiDetails.Status == "unhealthy" {
 				// Failed
-				conditions = append(conditions, fmt.Sprintf("phase='%s'", "Failed"))
+				query.where("phase = ?", "Failed")
 			} else if vmiDetails.Status == "warning" {
 				// Other than Running, Succeeded, Failed
-				conditions = append(conditions, fmt.Sprintf("phase!='%s' AND phase!='%s' AND phase!='%s'", "Running", "Succeeded", "Failed"))
+				query.whereNotIn("phase", []string{"Running", "Succeeded", "Failed"})
 			}
 		}
-
-		queryString = fmt.Sprintf("%s%s", queryString, strings.Join(conditions, " AND "))
 	}
 
-	log.Log.Println("queryString: ", queryString)
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+	resultsMap, err := d.getList("migrations", query, page, perPage, vmiDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -1443,9 +1536,9 @@ func (d *DatabaseInstance) GetVmiMigrations(page int, perPage int, vmiDetails *G
 func (d *DatabaseInstance) ListImportedMustGather() (imgList []ImportedMustGather, err error) {
 	imgList = []ImportedMustGather{}
 
-	queryString := "select name, importTime, gatherTime, insightsData from importedmustgathers"
+	query := d.scoped(newSelectQuery("select id, name, importTime, gatherTime, insightsData, cluster, clusterName from importedmustgathers")).order("id")
 
-	rows, err := d.db.Query(queryString)
+	rows, err := d.conn().Query(query.String(), query.Args()...)
 	if err != nil {
 		log.Log.Fatalln("failed to query imported must gathers - ", err)
 		return
@@ -1453,11 +1546,13 @@ func (d *DatabaseInstance) ListImportedMustGather() (imgList []ImportedMustGathe
 
 	for rows.Next() {
 		img := ImportedMustGather{}
-		err = rows.Scan(&img.Name, &img.ImportTime, &img.GatherTime, &img.InsightsData)
+		var clusterName sql.NullString
+		err = rows.Scan(&img.ID, &img.Name, &img.ImportTime, &img.GatherTime, &img.InsightsData, &img.Cluster, &clusterName)
 		if err != nil {
 			log.Log.Fatalln("failed to scan imported must gather - ", err)
 			return
 		}
+		img.ClusterName = clusterName.String
 		imgList = append(imgList, img)
 	}
 	return
@@ -1466,10 +1561,14 @@ func (d *DatabaseInstance) ListImportedMustGather() (imgList []ImportedMustGathe
 func (d *DatabaseInstance) GetImportedMustGather(name string) (img *ImportedMustGather, exists bool, err error) {
 	img = &ImportedMustGather{}
 
-	queryString := fmt.Sprintf("select name, importTime, gatherTime, insightsData from importedmustgathers where name = '%s' limit 1", name)
+	query := d.scoped(newSelectQuery("select id, name, importTime, gatherTime, insightsData, cluster, clusterName from importedmustgathers")).
+		where("name = ?", name).
+		order("id")
 
-	rows := d.db.QueryRow(queryString)
-	err = rows.Scan(&img.Name, &img.ImportTime, &img.GatherTime, &img.InsightsData)
+	var clusterName sql.NullString
+	rows := d.queryRow(query)
+	err = rows.Scan(&img.ID, &img.Name, &img.ImportTime, &img.GatherTime, &img.InsightsData, &img.Cluster, &clusterName)
+	img.ClusterName = clusterName.String
 	if err != nil {
 		exists = false
 		if err == sql.ErrNoRows {
@@ -1485,34 +1584,48 @@ func (d *DatabaseInstance) GetImportedMustGather(name string) (img *ImportedMust
 	return
 }
 
-func (d *DatabaseInstance) genericGet(queryString string, page int, perPage int) (map[string]interface{}, error) {
+func (d *DatabaseInstance) genericGet(query *selectQuery, page int, perPage int) (map[string]interface{}, error) {
 	response := map[string]interface{}{}
 	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
 	defer cancel()
 
-	limit := " "
-	if perPage != -1 {
-		limit = " limit " + strconv.Itoa((page-1)*perPage) + ", " + strconv.Itoa(perPage)
-	}
+	queryString, args := query.paginated(page, perPage)
 
-	log.Log.Println("queryString: ", queryString+limit)
-	stmt, err := d.db.PrepareContext(ctx, queryString+limit)
+	log.Log.Println("queryString: ", queryString)
+	stmt, err := d.prepare(ctx, queryString)
 	if err != nil {
 		return response, err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query()
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return response, err
 	}
 
 	defer rows.Close()
 
-	columns, err := rows.Columns()
+	data, err := scanRecords(rows)
 	if err != nil {
 		return response, err
 	}
+
+	meta, err := d.getMeta(page, perPage, query)
+	if err != nil {
+		return nil, err
+	}
+	response["data"] = data
+	response["meta"] = meta
+	return response, nil
+}
+
+// scanRecords reads every row into a map by column name, byte values are
+// returned as strings
+func scanRecords(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
 	data := []map[string]interface{}{}
 	count := len(columns)
 	values := make([]interface{}, count)
@@ -1525,7 +1638,7 @@ func (d *DatabaseInstance) genericGet(queryString string, page int, perPage int)
 	for rows.Next() {
 		err := rows.Scan(scanArgs...)
 		if err != nil {
-			return response, err
+			return nil, err
 		}
 		tbRecord := map[string]interface{}{}
 		for i, col := range columns {
@@ -1540,21 +1653,28 @@ func (d *DatabaseInstance) genericGet(queryString string, page int, perPage int)
 		data = append(data, tbRecord)
 
 	}
+	return data, rows.Err()
+}
 
-	meta, err := d.getMeta(page, perPage, queryString)
-	if err != nil {
-		return nil, err
-	}
-	response["data"] = data
-	response["meta"] = meta
-	return response, nil
+// queryRow runs a single row lookup with the query's bound arguments
+func (d *DatabaseInstance) queryRow(query *selectQuery) *sql.Row {
+	return d.conn().QueryRow(query.String(), query.Args()...)
+}
+
+// virtHandlerQuery looks up the virt-handler pod running on the given node
+func (d *DatabaseInstance) virtHandlerQuery(nodeName string) *selectQuery {
+	return d.scoped(newSelectQuery("select name from pods")).
+		where("nodeName = ?", nodeName).
+		where("name like ?", "virt-handler%")
 }
 
 func (d *DatabaseInstance) getPodUUIDByName(name string, namespace string) (string, error) {
 
 	var podUUID string
-	query := fmt.Sprintf("SELECT uuid from pods WHERE name='%s' AND namespace='%s'", name, namespace)
-	rows := d.db.QueryRow(query)
+	query := d.scoped(newSelectQuery("SELECT uuid from pods")).
+		where("name = ?", name).
+		where("namespace = ?", namespace)
+	rows := d.queryRow(query)
 
 	err := rows.Scan(&podUUID)
 	if err != nil {
@@ -1574,8 +1694,10 @@ func (d *DatabaseInstance) getVMICreationTimeByName(name string, namespace strin
 
 	var creationTime time.Time
 	var vmiUUID string
-	query := fmt.Sprintf("SELECT uuid, creationTime from vmis WHERE name='%s' AND namespace='%s'", name, namespace)
-	rows := d.db.QueryRow(query)
+	query := d.scoped(newSelectQuery("SELECT uuid, creationTime from vmis")).
+		where("name = ?", name).
+		where("namespace = ?", namespace)
+	rows := d.queryRow(query)
 
 	err := rows.Scan(&vmiUUID, &creationTime)
 	if err != nil {
@@ -1597,8 +1719,9 @@ func (d *DatabaseInstance) getSingleMigrationByUUID(uuid string) (*VirtualMachin
 	var startTime time.Time
 	var endTime time.Time
 
-	query := fmt.Sprintf("SELECT name, namespace, uuid, phase, vmiName, targetPod, creationTime, endTimestamp, sourceNode, targetNode, completed, failed from vmimigrations WHERE uuid='%s'", uuid)
-	rows := d.db.QueryRow(query)
+	query := d.scoped(newSelectQuery("SELECT name, namespace, uuid, phase, vmiName, targetPod, creationTime, endTimestamp, sourceNode, targetNode, completed, failed from vmimigrations")).
+		where("uuid = ?", uuid)
+	rows := d.queryRow(query)
 	var targetNode string
 	err := rows.Scan(&vmim.Name, &vmim.Namespace, &vmim.UUID, &vmim.Phase, &vmim.VMIName, &vmim.TargetPod,
 		&startTime, &endTime, &vmim.SourceNode, &targetNode, &vmim.Completed,
@@ -1635,11 +1758,31 @@ func (d *DatabaseInstance) getSingleMigrationByUUID(uuid string) (*VirtualMachin
 	return &vmim, nil
 }
 
-func (d *DatabaseInstance) GetSubscriptions(page int, perPage int) (map[string]interface{}, error) {
-	queryString := "SELECT name, namespace, uuid, source, sourceNamespace, startingCSV, currentCSV, installedCSV, state, creationTime, content from subscriptions"
-	resultsMap, err := d.genericGet(queryString, page, perPage)
+func (d *DatabaseInstance) GetSubscriptions(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error) {
+	query := d.scoped(listQuery("subscriptions"))
+	if queryDetails != nil {
+		if queryDetails.Name != "" {
+			query.where("name = ?", queryDetails.Name)
+		}
+		if queryDetails.Namespace != "" {
+			query.where("namespace = ?", queryDetails.Namespace)
+		}
+		if queryDetails.UUID != "" {
+			query.where("uuid = ?", queryDetails.UUID)
+		}
+	}
+	resultsMap, err := d.getList("subscriptions", query, page, perPage, queryDetails)
 	if err != nil {
 		return nil, err
 	}
 	return resultsMap, nil
 }
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hostileNames are stored and looked up as is, none of them must change
+// the statements or match another object
+var hostileNames = []string{
+	`it's`,
+	`say "hi"`,
+	`back\slash`,
+	`100%`,
+	`under_score`,
+	`'; DROP TABLE pods; --`,
+	`" OR "1"="1`,
+}
+
+// decoyNames would match some of the hostileNames if they were
+// interpreted as LIKE patterns or unescaped strings
+var decoyNames = []string{"1000", "underXscore", "its", "say hi"}
+
+// newTestStore returns an empty, migrated SQLite store living in memory
+func newTestStore(t *testing.T) *DatabaseInstance {
+	t.Helper()
+	store, err := NewSQLiteDatabaseInstance(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+	t.Cleanup(func() { store.Shutdown() })
+	if err := store.InitTables(); err != nil {
+		t.Fatalf("failed to migrate the store: %v", err)
+	}
+	return store
+}
+
+func testContent(t *testing.T, object interface{}) json.RawMessage {
+	t.Helper()
+	content, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func testMetadata(name string, namespace string, uid string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": namespace, "uid": uid},
+	}
+}
+
+// storeNamedObjects stores an object of every kind under each name, its
+// namespace and UID derive from the name
+func storeNamedObjects(t *testing.T, store *DatabaseInstance, names []string) {
+	t.Helper()
+	created := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	for _, name := range names {
+		namespace, uid := "ns-"+name, "uid-"+name
+		content := testContent(t, testMetadata(name, namespace, uid))
+		for kind, err := range map[string]error{
+			"pod": store.StorePod(&Pod{Name: name, Namespace: namespace, UUID: uid, Phase: "Running", NodeName: "node-" + name,
+				CreationTime: created, PVCs: "", Content: content, CreatedBy: "vmi-" + uid}),
+			"vm":  store.StoreVm(&VirtualMachine{Name: name, Namespace: namespace, UUID: uid, Content: content}),
+			"vmi": store.StoreVmi(&VirtualMachineInstance{Name: name, Namespace: namespace, UUID: "vmi-" + uid, Phase: "Running", CreationTime: created, Content: content}),
+			"migration": store.StoreVmiMigration(&VirtualMachineInstanceMigration{Name: name, Namespace: namespace, UUID: "migration-" + uid,
+				VMIName: name, Phase: "Succeeded", CreationTime: created, EndTimestamp: created, Content: content}),
+			"pvc": store.StorePVC(&PersistentVolumeClaim{Name: name, Namespace: namespace, UUID: "pvc-" + uid, Phase: "Bound",
+				CreationTime: created, Content: content}),
+			"node":         store.StoreNode(&Node{Name: "node-" + name, SystemUUID: "node-" + uid, Status: "Ready", Content: content}),
+			"subscription": store.StoreSubscription(&Subscription{Name: name, Namespace: namespace, UUID: "sub-" + uid, CreationTime: created, Content: content}),
+		} {
+			if err != nil {
+				t.Fatalf("failed to store the %s %q: %v", kind, name, err)
+			}
+		}
+	}
+}
+
+func listNames(t *testing.T, result map[string]interface{}, err error, column string) []string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	names := []string{}
+	for _, row := range result["data"].([]map[string]interface{}) {
+		names = append(names, row[column].(string))
+	}
+	return names
+}
+
+func TestListsMatchHostileNamesExactly(t *testing.T) {
+	store := newTestStore(t)
+	storeNamedObjects(t, store, append(append([]string{}, hostileNames...), decoyNames...))
+
+	lists := []struct {
+		kind   string
+		list   func(page int, perPage int, details *GenericQueryDetails) (map[string]interface{}, error)
+		column string
+		// uid derives the UID of an object of the kind from the name
+		uid func(name string) string
+	}{
+		{"pods", store.GetPods, "name", func(name string) string { return "uid-" + name }},
+		{"vms", store.GetVms, "name", func(name string) string { return "uid-" + name }},
+		{"vmis", store.GetVmis, "name", func(name string) string { return "vmi-uid-" + name }},
+		{"migrations", store.GetVmiMigrations, "vmiName", func(name string) string { return "migration-uid-" + name }},
+		{"pvcs", store.GetPVCs, "name", func(name string) string { return "pvc-uid-" + name }},
+		{"subscriptions", store.GetSubscriptions, "name", func(name string) string { return "sub-uid-" + name }},
+	}
+	for _, list := range lists {
+		for _, name := range hostileNames {
+			for filter, details := range map[string]*GenericQueryDetails{
+				"name":      {Name: name},
+				"namespace": {Namespace: "ns-" + name},
+				"uid":       {UUID: list.uid(name)},
+			} {
+				t.Run(list.kind+"/"+filter+"/"+name, func(t *testing.T) {
+					result, err := list.list(1, -1, details)
+					names := listNames(t, result, err, list.column)
+					if len(names) != 1 || names[0] != name {
+						t.Errorf("got %q, want only %q", names, name)
+					}
+				})
+			}
+		}
+	}
+
+	for _, name := range hostileNames {
+		t.Run("nodes/name/"+name, func(t *testing.T) {
+			result, err := store.GetNodes(1, -1, &GenericQueryDetails{Name: "node-" + name})
+			names := listNames(t, result, err, "name")
+			if len(names) != 1 || names[0] != "node-"+name {
+				t.Errorf("got %q, want only %q", names, "node-"+name)
+			}
+		})
+	}
+}
+
+func TestObjectLookupsWithHostileValues(t *testing.T) {
+	store := newTestStore(t)
+	storeNamedObjects(t, store, hostileNames)
+
+	for _, name := range hostileNames {
+		uid := "uid-" + name
+		t.Run("found/"+name, func(t *testing.T) {
+			pod, err := store.GetPodObject(uid)
+			if err != nil || pod.Name != name {
+				t.Errorf("GetPodObject(%q) = %v, %v, want the pod %q", uid, pod, err, name)
+			}
+			vm, err := store.GetVMObject(uid)
+			if err != nil || vm.Name != name {
+				t.Errorf("GetVMObject(%q) = %v, %v, want the VM %q", uid, vm, err, name)
+			}
+			vmi, err := store.GetVMIObject("vmi-" + uid)
+			if err != nil || vmi.Name != name {
+				t.Errorf("GetVMIObject(%q) = %v, %v, want the VMI %q", "vmi-"+uid, vmi, err, name)
+			}
+			pvc, err := store.GetPVCObject("pvc-" + uid)
+			if err != nil || pvc.Name != name {
+				t.Errorf("GetPVCObject(%q) = %v, %v, want the claim %q", "pvc-"+uid, pvc, err, name)
+			}
+			node, err := store.GetNodeObject("node-" + uid)
+			if err != nil || node.Name != name {
+				t.Errorf("GetNodeObject(%q) = %v, %v, want the node of %q", "node-"+uid, node, err, name)
+			}
+			if data, err := store.GetPodQueryParams(uid); err != nil || data.SourcePod != name {
+				t.Errorf("GetPodQueryParams(%q) = %v, %v, want the pod %q", uid, data.SourcePod, err, name)
+			}
+			if summary, err := store.GetNamespaceSummary("ns-" + name); err != nil || summary.Pods.Total != 1 {
+				t.Errorf("GetNamespaceSummary(%q) = %v, %v, want a single pod", "ns-"+name, summary, err)
+			}
+			if data, err := store.GetNamespaceQueryParams("ns-" + name); err != nil || data.Namespace != "ns-"+name {
+				t.Errorf("GetNamespaceQueryParams(%q) = %v, %v", "ns-"+name, data, err)
+			}
+		})
+
+		missing := "missing-" + name
+		t.Run("missing/"+name, func(t *testing.T) {
+			for lookup, err := range map[string]error{
+				"GetPodObject":                 second(store.GetPodObject(missing)),
+				"GetVMObject":                  second(store.GetVMObject(missing)),
+				"GetVMIObject":                 second(store.GetVMIObject(missing)),
+				"GetPVCObject":                 second(store.GetPVCObject(missing)),
+				"GetNodeObject":                second(store.GetNodeObject(missing)),
+				"GetPodQueryParams":            second(store.GetPodQueryParams(missing)),
+				"GetVMIQueryParams":            second(store.GetVMIQueryParams(missing, name)),
+				"GetFullVMIHistoryQueryParams": second(store.GetFullVMIHistoryQueryParams(missing)),
+				"GetMigrationQueryParams":      second(store.GetMigrationQueryParams(missing)),
+				"GetNodeQueryParams":           second(store.GetNodeQueryParams(missing)),
+				"GetPVCQueryParams":            second(store.GetPVCQueryParams(missing)),
+				"GetSubscriptionQueryParams":   second(store.GetSubscriptionQueryParams(missing)),
+				"GetCSVQueryParams":            second(store.GetCSVQueryParams(missing)),
+				"GetNamespaceQueryParams":      second(store.GetNamespaceQueryParams(missing)),
+				"GetNamespaceSummary":          second(store.GetNamespaceSummary(missing)),
+				"GetObjectHistory":             second(store.GetObjectHistory("pods", missing)),
+				"GetVMITimeline":               second(store.GetVMITimeline(missing)),
+				"GetPodPVCs":                   second(store.GetPodPVCs(missing)),
+				"GetVMIPVCs":                   second(store.GetVMIPVCs(missing)),
+			} {
+				if !IsNotFound(err) {
+					t.Errorf("%s(%q) = %v, want not found", lookup, missing, err)
+				}
+			}
+			for lookup, err := range map[string]error{
+				"Resolve":      second(store.Resolve(missing)),
+				"Search":       second(store.Search(missing, nil, 10)),
+				"ListClusters": second(store.ListClusters()),
+			} {
+				if err != nil {
+					t.Errorf("%s(%q) failed: %v", lookup, missing, err)
+				}
+			}
+			if _, exists, err := store.GetImportedMustGather(missing); exists || err != nil {
+				t.Errorf("GetImportedMustGather(%q) = %v, %v, want not found", missing, exists, err)
+			}
+		})
+	}
+}
+
+func TestSearchDoesNotExpandWildcards(t *testing.T) {
+	store := newTestStore(t)
+	storeNamedObjects(t, store, append(append([]string{}, hostileNames...), decoyNames...))
+
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			results, err := store.Search(name, []string{"pods"}, 50)
+			if err != nil {
+				t.Fatalf("Search(%q) failed: %v", name, err)
+			}
+			if len(results) != 1 || results[0].Name != name {
+				t.Errorf("Search(%q) = %v, want only the pod %q", name, results, name)
+			}
+		})
+	}
+}
+
+// second drops the first result of a lookup
+func second(_ interface{}, err error) error {
+	return err
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// dialect hides the SQL differences between the supported database engines
+type dialect interface {
+	driverName() string
+	dataSourceName() string
+	// configure tunes the connection pool for the engine
+	configure(db *sql.DB)
+	// upsert renders an INSERT statement which, for a row that already
+	// exists under the same key, only updates the listed columns
+	upsert(q upsertQuery) string
+	// autoIncrementID renders an integer "id" primary key column definition
+	autoIncrementID() string
+}
+
+type upsertQuery struct {
+	table   string
+	columns []string
+	key     string
+	update  []string
+}
+
+// insertPrefix renders the common "INSERT INTO table(cols) values (?, ...)" part
+func (q upsertQuery) insertPrefix() string {
+	return "INSERT INTO " + q.table + "(" + strings.Join(q.columns, ", ") + ") values (" + placeholders(len(q.columns)) + ")"
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpsert(t *testing.T) {
+	queries := map[string]upsertQuery{
+		"insert":  {table: "t", columns: []string{"a", "b"}},
+		"keep":    {table: "t", columns: []string{"a", "b", "c"}, key: "a, b"},
+		"replace": replacing(upsertQuery{table: "t", columns: []string{"a", "b", "c"}, key: "a, b"}),
+	}
+	tests := []struct {
+		dialect dialect
+		query   string
+		want    string
+	}{
+		{&mysqlDialect{}, "insert", "INSERT INTO t(a, b) values (?, ?);"},
+		{&mysqlDialect{}, "keep", "INSERT INTO t(a, b, c) values (?, ?, ?) ON DUPLICATE KEY UPDATE a=a;"},
+		{&mysqlDialect{}, "replace", "INSERT INTO t(a, b, c) values (?, ?, ?) ON DUPLICATE KEY UPDATE c=VALUES(c);"},
+		{&sqliteDialect{}, "insert", "INSERT INTO t(a, b) values (?, ?);"},
+		{&sqliteDialect{}, "keep", "INSERT INTO t(a, b, c) values (?, ?, ?) ON CONFLICT(a, b) DO NOTHING;"},
+		{&sqliteDialect{}, "replace", "INSERT INTO t(a, b, c) values (?, ?, ?) ON CONFLICT(a, b) DO UPDATE SET c=excluded.c;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.driverName()+"/"+tt.query, func(t *testing.T) {
+			if got := tt.dialect.upsert(queries[tt.query]); got != tt.want {
+				t.Errorf("upsert = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrationsAreAppliedOnce(t *testing.T) {
+	store := newTestStore(t)
+	// a restart finds the schema up to date
+	if err := store.InitTables(); err != nil {
+		t.Fatalf("failed to migrate the store again: %v", err)
+	}
+	version, err := store.schemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest := migrations[len(migrations)-1].version; version != latest {
+		t.Errorf("schema version = %d, want %d", version, latest)
+	}
+	var applied int
+	if err := store.db.QueryRow("select count(*) from schema_version").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(migrations) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+}
+
+func TestDefaultNoiseFilters(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.InitTables(); err != nil {
+		t.Fatalf("failed to migrate the store again: %v", err)
+	}
+	filters, err := store.ListNoiseFilters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{
+		"certificate with common name 'kubevirt.io:system:client:virt-handler' retrieved.": true,
+		"forcing resync":                     false,
+		"attempting to acquire leader lease": false,
+		"Heartbeat sent":                     false,
+	}
+	if len(filters) != len(want) {
+		t.Fatalf("got %d noise filters, want %d: %v", len(filters), len(want), filters)
+	}
+	for _, filter := range filters {
+		if enabled, ok := want[filter.Phrase]; !ok || filter.Enabled != enabled || filter.Description == "" {
+			t.Errorf("unexpected noise filter %+v", filter)
+		}
+	}
+}
+
+func TestUpsertRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+	created := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// the object store replaces every column of an existing pod
+	for _, phase := range []string{"Pending", "Running"} {
+		if err := store.StorePod(&Pod{Name: "pod", Namespace: "ns", UUID: "uid", Phase: phase, NodeName: "node-" + phase,
+			CreationTime: created, Content: []byte(`{}`)}); err != nil {
+			t.Fatalf("failed to store the pod: %v", err)
+		}
+	}
+	var count int
+	var phase, node string
+	if err := store.db.QueryRow("select count(*), max(phase), max(nodeName) from pods").Scan(&count, &phase, &node); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || phase != "Running" || node != "node-Running" {
+		t.Errorf("got %d pods in phase %q on %q, want a single one Running on node-Running", count, phase, node)
+	}
+
+	// events only update their counters
+	for _, event := range []Event{
+		{UUID: "event", Name: "first", Reason: "Created", Count: 1, Content: []byte(`{}`)},
+		{UUID: "event", Name: "second", Reason: "Changed", Count: 3, Content: []byte(`{}`)},
+	} {
+		if err := store.StoreEvent(&event); err != nil {
+			t.Fatalf("failed to store the event: %v", err)
+		}
+	}
+	var name, reason string
+	var times int
+	if err := store.db.QueryRow("select count(*), max(name), max(reason), max(count) from events").Scan(&count, &name, &reason, &times); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || name != "first" || reason != "Created" || times != 3 {
+		t.Errorf("got %d events, %q %q counted %d, want the first one counted 3", count, name, reason, times)
+	}
+
+	// a key without columns to update keeps the existing row
+	keep := upsertQuery{table: "noisefilters", columns: []string{"id", "phrase", "enabled"}, key: "id"}
+	for _, phrase := range []string{"kept", "ignored"} {
+		if _, err := store.db.Exec(store.dialect.upsert(keep), 1000, phrase, true); err != nil {
+			t.Fatalf("failed to upsert %q: %v", phrase, err)
+		}
+	}
+	if err := store.db.QueryRow("select phrase from noisefilters where id = 1000").Scan(&name); err != nil || name != "kept" {
+		t.Errorf("got the phrase %q, %v, want kept", name, err)
+	}
+}
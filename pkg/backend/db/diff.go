@@ -0,0 +1,341 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"logsviewer/pkg/backend/log"
+)
+
+// diffIgnoredFields change on every gather without telling anything
+var diffIgnoredFields = []string{"resourceVersion", "managedFields", "lastHeartbeatTime", "lastProbeTime"}
+
+// DiffImports compares the snapshots of two imports: the objects only found
+// in one of them, and for those found in both, the phase, node and fields
+// that changed, and the restarts of pods. An empty kinds compares every
+// kind. The insights findings of both imports are compared by key.
+func (d *DatabaseInstance) DiffImports(fromID int, toID int, kinds []string) (*ImportDiff, error) {
+	diff := &ImportDiff{
+		Added:    []ObjectRef{},
+		Removed:  []ObjectRef{},
+		Changed:  []ObjectChange{},
+		Appeared: []InsightsFinding{},
+		Resolved: []InsightsFinding{},
+	}
+
+	var err error
+	if diff.From, err = d.getImport(fromID); err != nil {
+		return nil, err
+	}
+	if diff.To, err = d.getImport(toID); err != nil {
+		return nil, err
+	}
+	if diff.From.Cluster != diff.To.Cluster {
+		return nil, fmt.Errorf("%w, import %d is of cluster %s and import %d of cluster %s", ErrMixedClusters, fromID, diff.From.Cluster, toID, diff.To.Cluster)
+	}
+	if diff.From.GatherTime.After(diff.To.GatherTime) {
+		diff.Warnings = append(diff.Warnings, fmt.Sprintf("import %d was gathered after import %d, the changes are reversed", fromID, toID))
+	}
+	d.diffInsights(diff)
+	diff.From.InsightsData = ""
+	diff.To.InsightsData = ""
+
+	from, err := d.importSnapshots(fromID, kinds)
+	if err != nil {
+		return nil, err
+	}
+	to, err := d.importSnapshots(toID, kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, before := range from {
+		after, exists := to[key]
+		if !exists {
+			diff.Removed = append(diff.Removed, snapshotRef(before))
+			continue
+		}
+		if change, changed := diffSnapshots(before, after); changed {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for key, after := range to {
+		if _, exists := from[key]; !exists {
+			diff.Added = append(diff.Added, snapshotRef(after))
+		}
+	}
+
+	sortRefs(diff.Added)
+	sortRefs(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return refLess(diff.Changed[i].ObjectRef, diff.Changed[j].ObjectRef)
+	})
+	return diff, nil
+}
+
+func (d *DatabaseInstance) getImport(id int) (ImportedMustGather, error) {
+	img := ImportedMustGather{}
+	var insightsData, clusterName sql.NullString
+	err := d.queryRow(d.scoped(newSelectQuery("select id, name, importTime, gatherTime, insightsData, cluster, clusterName from importedmustgathers")).where("id = ?", id)).
+		Scan(&img.ID, &img.Name, &img.ImportTime, &img.GatherTime, &insightsData, &img.Cluster, &clusterName)
+	if err == sql.ErrNoRows {
+		log.Log.Println("can't find any import with this id: ", id)
+	}
+	img.InsightsData = insightsData.String
+	img.ClusterName = clusterName.String
+	return img, err
+}
+
+// importSnapshots reads the snapshots of an import by kind and uid
+func (d *DatabaseInstance) importSnapshots(importID int, kinds []string) (map[string]Snapshot, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 30*time.Second)
+	defer cancel()
+
+	query := newSelectQuery("select kind, uuid, name, namespace, content from snapshots").where("importId = ?", importID)
+	if len(kinds) > 0 {
+		query.whereIn("kind", kinds)
+	}
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := map[string]Snapshot{}
+	for rows.Next() {
+		snapshot := Snapshot{ImportID: importID}
+		var namespace sql.NullString
+		var content []byte
+		if err := rows.Scan(&snapshot.Kind, &snapshot.UUID, &snapshot.Name, &namespace, &content); err != nil {
+			return nil, err
+		}
+		snapshot.Namespace = namespace.String
+		snapshot.Content = json.RawMessage(content)
+		snapshots[snapshot.Kind+"/"+snapshot.UUID] = snapshot
+	}
+	return snapshots, rows.Err()
+}
+
+func snapshotRef(snapshot Snapshot) ObjectRef {
+	return ObjectRef{Kind: snapshot.Kind, UID: snapshot.UUID, Name: snapshot.Name, Namespace: snapshot.Namespace}
+}
+
+func refLess(a ObjectRef, b ObjectRef) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.UID < b.UID
+}
+
+func sortRefs(refs []ObjectRef) {
+	sort.Slice(refs, func(i, j int) bool { return refLess(refs[i], refs[j]) })
+}
+
+// diffSnapshots compares two states of an object
+func diffSnapshots(before Snapshot, after Snapshot) (ObjectChange, bool) {
+	change := ObjectChange{ObjectRef: snapshotRef(after), Fields: []FieldChange{}}
+
+	var from, to interface{}
+	if err := json.Unmarshal(before.Content, &from); err != nil {
+		return change, false
+	}
+	if err := json.Unmarshal(after.Content, &to); err != nil {
+		return change, false
+	}
+	diffFields("", from, to, &change.Fields)
+
+	if phaseFrom, phaseTo := snapshotPhase(before.Kind, from), snapshotPhase(after.Kind, to); phaseFrom != phaseTo {
+		change.Phase = &ValueChange{From: phaseFrom, To: phaseTo}
+	}
+	if nodeFrom, nodeTo := snapshotNode(before.Kind, from), snapshotNode(after.Kind, to); nodeFrom != nodeTo {
+		change.Node = &ValueChange{From: nodeFrom, To: nodeTo}
+	}
+	if before.Kind == "pods" {
+		if restarts := podRestarts(to) - podRestarts(from); restarts > 0 {
+			change.Restarts = restarts
+		}
+	}
+	return change, len(change.Fields) > 0
+}
+
+// diffFields appends the fields that differ between from and to, arrays are
+// compared element by element
+func diffFields(path string, from interface{}, to interface{}, changes *[]FieldChange) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+	if fromIsMap && toIsMap {
+		keys := []string{}
+		for key := range fromMap {
+			keys = append(keys, key)
+		}
+		for key := range toMap {
+			if _, exists := fromMap[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if contains(diffIgnoredFields, key) {
+				continue
+			}
+			diffFields(fieldPath(path, key), fromMap[key], toMap[key], changes)
+		}
+		return
+	}
+
+	fromList, fromIsList := from.([]interface{})
+	toList, toIsList := to.([]interface{})
+	if fromIsList && toIsList {
+		for i := 0; i < len(fromList) || i < len(toList); i++ {
+			var fromItem, toItem interface{}
+			if i < len(fromList) {
+				fromItem = fromList[i]
+			}
+			if i < len(toList) {
+				toItem = toList[i]
+			}
+			diffFields(fmt.Sprintf("%s[%d]", path, i), fromItem, toItem, changes)
+		}
+		return
+	}
+
+	fromJSON, _ := json.Marshal(from)
+	toJSON, _ := json.Marshal(to)
+	if string(fromJSON) == string(toJSON) {
+		return
+	}
+	*changes = append(*changes, FieldChange{Path: path, From: renderYAML(from), To: renderYAML(to)})
+}
+
+// fieldPath appends key to path, quoting the keys holding dots such as the
+// annotations
+func fieldPath(path string, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return path + "['" + key + "']"
+	}
+	return path + "." + key
+}
+
+func renderYAML(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	rendered, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(string(rendered), "\n")
+}
+
+// snapshotPhase is the phase, status or state of an object, depending on its kind
+func snapshotPhase(kind string, object interface{}) string {
+	switch kind {
+	case "vms":
+		return lookupString(object, "status", "printableStatus")
+	case "subscriptions":
+		return lookupString(object, "status", "state")
+	case "nodes":
+		conditions, _ := lookup(object, "status", "conditions").([]interface{})
+		for _, condition := range conditions {
+			if lookupString(condition, "type") == "Ready" {
+				return "Ready=" + lookupString(condition, "status")
+			}
+		}
+		return ""
+	}
+	return lookupString(object, "status", "phase")
+}
+
+// snapshotNode is the node a pod or VMI runs on
+func snapshotNode(kind string, object interface{}) string {
+	switch kind {
+	case "pods":
+		return lookupString(object, "spec", "nodeName")
+	case "vmis":
+		return lookupString(object, "status", "nodeName")
+	}
+	return ""
+}
+
+func podRestarts(pod interface{}) int {
+	restarts := 0
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _ := lookup(pod, "status", field).([]interface{})
+		for _, status := range statuses {
+			if count, ok := lookup(status, "restartCount").(float64); ok {
+				restarts += int(count)
+			}
+		}
+	}
+	return restarts
+}
+
+func lookup(object interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		fields, ok := object.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = fields[key]
+	}
+	return object
+}
+
+func lookupString(object interface{}, keys ...string) string {
+	value, _ := lookup(object, keys...).(string)
+	return value
+}
+
+// diffInsights compares the insights reports of both imports by key and
+// component, a warning tells when either import has no insights data
+func (d *DatabaseInstance) diffInsights(diff *ImportDiff) {
+	type report struct {
+		Reports []InsightsFinding `json:"reports"`
+	}
+	parse := func(img ImportedMustGather) (map[string]InsightsFinding, bool) {
+		findings := map[string]InsightsFinding{}
+		parsed := report{}
+		if img.InsightsData == "" || json.Unmarshal([]byte(img.InsightsData), &parsed) != nil {
+			diff.Warnings = append(diff.Warnings, fmt.Sprintf("import %d has no insights data, its findings can't be compared", img.ID))
+			return findings, false
+		}
+		for _, finding := range parsed.Reports {
+			findings[finding.Key+"/"+finding.Component] = finding
+		}
+		return findings, true
+	}
+	from, fromOK := parse(diff.From)
+	to, toOK := parse(diff.To)
+	if !fromOK || !toOK {
+		return
+	}
+
+	for key, finding := range to {
+		if _, exists := from[key]; !exists {
+			diff.Appeared = append(diff.Appeared, finding)
+		}
+	}
+	for key, finding := range from {
+		if _, exists := to[key]; !exists {
+			diff.Resolved = append(diff.Resolved, finding)
+		}
+	}
+	for _, findings := range [][]InsightsFinding{diff.Appeared, diff.Resolved} {
+		sort.Slice(findings, func(i, j int) bool {
+			return findings[i].Key+"/"+findings[i].Component < findings[j].Key+"/"+findings[j].Component
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// diffFixture is an import of testdata/diff, its objects by snapshot kind
+type diffFixture struct {
+	GatherTime time.Time                    `json:"gatherTime"`
+	Insights   json.RawMessage              `json:"insights"`
+	Objects    map[string][]json.RawMessage `json:"objects"`
+}
+
+// importFixture stores the import and the snapshots of a fixture
+func importFixture(t *testing.T, store *DatabaseInstance, name string) int {
+	t.Helper()
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "diff", name+".yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := diffFixture{}
+	if err := yaml.Unmarshal(content, &fixture); err != nil {
+		t.Fatalf("failed to parse the fixture %s: %v", name, err)
+	}
+
+	img := &ImportedMustGather{Name: name, ImportTime: time.Now().UTC(), GatherTime: fixture.GatherTime,
+		InsightsData: string(fixture.Insights), Cluster: testCluster}
+	if err := store.StoreImportedMustGather(img); err != nil {
+		t.Fatal(err)
+	}
+	for kind, objects := range fixture.Objects {
+		for _, object := range objects {
+			meta := struct {
+				Metadata struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+					UID       string `json:"uid"`
+				} `json:"metadata"`
+			}{}
+			if err := json.Unmarshal(object, &meta); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.StoreSnapshot(&Snapshot{Kind: kind, UUID: meta.Metadata.UID, ImportID: img.ID, Name: meta.Metadata.Name,
+				Namespace: meta.Metadata.Namespace, GatherTime: fixture.GatherTime, Content: object}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return img.ID
+}
+
+func findingKeys(findings []InsightsFinding) []string {
+	keys := []string{}
+	for _, finding := range findings {
+		keys = append(keys, finding.Key)
+	}
+	return keys
+}
+
+func TestDiffImports(t *testing.T) {
+	store := newTestStore(t)
+	before := importFixture(t, store, "before")
+	after := importFixture(t, store, "after")
+
+	diff, err := store.DiffImports(before, after, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Warnings) > 0 {
+		t.Errorf("unexpected warnings %q", diff.Warnings)
+	}
+	if want := []ObjectRef{{Kind: "pods", UID: "pod-added", Name: "virt-launcher-fedora-klmno", Namespace: "vms"}}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("added %v, want %v", diff.Added, want)
+	}
+	if want := []ObjectRef{{Kind: "pods", UID: "pod-removed", Name: "importer-rootdisk", Namespace: "vms"}}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("removed %v, want %v", diff.Removed, want)
+	}
+
+	want := []ObjectChange{
+		{
+			ObjectRef: ObjectRef{Kind: "migrations", UID: "migration-done", Name: "fedora-migration", Namespace: "vms"},
+			Phase:     &ValueChange{From: "Running", To: "Succeeded"},
+			Fields:    []FieldChange{{Path: ".status.phase", From: "Running", To: "Succeeded"}},
+		},
+		{
+			// the heartbeat changes on every gather and is left out
+			ObjectRef: ObjectRef{Kind: "nodes", UID: "node-a", Name: "node-a"},
+			Phase:     &ValueChange{From: "Ready=True", To: "Ready=False"},
+			Fields:    []FieldChange{{Path: ".status.conditions[0].status", From: `"True"`, To: `"False"`}},
+		},
+		{
+			// so is the resourceVersion
+			ObjectRef: ObjectRef{Kind: "pods", UID: "pod-moved", Name: "virt-launcher-fedora-abcde", Namespace: "vms"},
+			Phase:     &ValueChange{From: "Pending", To: "Running"},
+			Node:      &ValueChange{From: "node-a", To: "node-b"},
+			Restarts:  2,
+			Fields: []FieldChange{
+				{Path: ".spec.nodeName", From: "node-a", To: "node-b"},
+				{Path: ".status.containerStatuses[0].restartCount", From: "1", To: "3"},
+				{Path: ".status.phase", From: "Pending", To: "Running"},
+			},
+		},
+		{
+			ObjectRef: ObjectRef{Kind: "pvcs", UID: "pvc-bound", Name: "rootdisk", Namespace: "vms"},
+			Phase:     &ValueChange{From: "Pending", To: "Bound"},
+			Fields: []FieldChange{
+				{Path: ".spec.accessModes[1]", To: "ReadWriteMany"},
+				{Path: ".status.phase", From: "Pending", To: "Bound"},
+			},
+		},
+		{
+			ObjectRef: ObjectRef{Kind: "subscriptions", UID: "sub-upgraded", Name: "kubevirt-hyperconverged", Namespace: "openshift-cnv"},
+			Phase:     &ValueChange{From: "UpgradePending", To: "AtLatestKnown"},
+			Fields: []FieldChange{
+				{Path: ".status.installedCSV", From: "kubevirt-hyperconverged-operator.v4.12.0", To: "kubevirt-hyperconverged-operator.v4.13.0"},
+				{Path: ".status.state", From: "UpgradePending", To: "AtLatestKnown"},
+			},
+		},
+		{
+			ObjectRef: ObjectRef{Kind: "vmis", UID: "vmi-moved", Name: "fedora", Namespace: "vms"},
+			Phase:     &ValueChange{From: "Scheduled", To: "Running"},
+			Node:      &ValueChange{From: "node-a", To: "node-b"},
+			Fields: []FieldChange{
+				{Path: ".status.nodeName", From: "node-a", To: "node-b"},
+				{Path: ".status.phase", From: "Scheduled", To: "Running"},
+			},
+		},
+		{
+			// keys holding dots are quoted
+			ObjectRef: ObjectRef{Kind: "vms", UID: "vm-started", Name: "fedora", Namespace: "vms"},
+			Phase:     &ValueChange{From: "Stopped", To: "Running"},
+			Fields: []FieldChange{
+				{Path: ".metadata.labels['kubevirt.io/size']", From: "small", To: "large"},
+				{Path: ".spec.running", From: "false", To: "true"},
+				{Path: ".status.printableStatus", From: "Stopped", To: "Running"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(diff.Changed, want) {
+		got, _ := json.MarshalIndent(diff.Changed, "", "  ")
+		expected, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("changed:\n%s\nwant:\n%s", got, expected)
+	}
+
+	if keys := findingKeys(diff.Appeared); !reflect.DeepEqual(keys, []string{"MIGRATION_FAILED"}) {
+		t.Errorf("appeared %q, want MIGRATION_FAILED", keys)
+	}
+	if keys := findingKeys(diff.Resolved); !reflect.DeepEqual(keys, []string{"NODE_NOT_READY"}) {
+		t.Errorf("resolved %q, want NODE_NOT_READY", keys)
+	}
+	if diff.From.InsightsData != "" || diff.To.InsightsData != "" {
+		t.Error("the insights data of the imports is returned")
+	}
+}
+
+func TestDiffImportsOfKinds(t *testing.T) {
+	store := newTestStore(t)
+	before := importFixture(t, store, "before")
+	after := importFixture(t, store, "after")
+
+	diff, err := store.DiffImports(before, after, []string{"vmis", "nodes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("added %v and removed %v, want no pod", diff.Added, diff.Removed)
+	}
+	kinds := []string{}
+	for _, change := range diff.Changed {
+		kinds = append(kinds, change.Kind)
+	}
+	if !reflect.DeepEqual(kinds, []string{"nodes", "vmis"}) {
+		t.Errorf("changed kinds %q, want nodes and vmis", kinds)
+	}
+}
+
+func TestDiffImportsReversed(t *testing.T) {
+	store := newTestStore(t)
+	before := importFixture(t, store, "before")
+	after := importFixture(t, store, "after")
+
+	diff, err := store.DiffImports(after, before, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Warnings) != 1 || !strings.Contains(diff.Warnings[0], "reversed") {
+		t.Errorf("warnings %q, want the changes to be told reversed", diff.Warnings)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].UID != "pod-removed" || len(diff.Removed) != 1 || diff.Removed[0].UID != "pod-added" {
+		t.Errorf("added %v and removed %v, want them swapped", diff.Added, diff.Removed)
+	}
+	// restarts only count up
+	for _, change := range diff.Changed {
+		if change.UID == "pod-moved" && (change.Restarts != 0 || change.Phase.To != "Pending") {
+			t.Errorf("pod-moved changed %+v, want back to Pending without restarts", change)
+		}
+	}
+	if keys := findingKeys(diff.Appeared); !reflect.DeepEqual(keys, []string{"NODE_NOT_READY"}) {
+		t.Errorf("appeared %q, want NODE_NOT_READY", keys)
+	}
+}
+
+func TestDiffImportsWithoutInsights(t *testing.T) {
+	store := newTestStore(t)
+	before := importFixture(t, store, "before")
+	if _, err := store.db.Exec("UPDATE importedmustgathers SET insightsData = '' WHERE id = ?", before); err != nil {
+		t.Fatal(err)
+	}
+	after := importFixture(t, store, "after")
+
+	diff, err := store.DiffImports(before, after, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Appeared) != 0 || len(diff.Resolved) != 0 {
+		t.Errorf("appeared %v and resolved %v, want no findings compared", diff.Appeared, diff.Resolved)
+	}
+	if len(diff.Warnings) != 1 || !strings.Contains(diff.Warnings[0], "no insights data") {
+		t.Errorf("warnings %q, want the missing insights data told", diff.Warnings)
+	}
+
+	if _, err := store.DiffImports(before, after+1, nil); !IsNotFound(err) {
+		t.Errorf("diffing a missing import returned %v, want not found", err)
+	}
+}
+
+func TestDiffImportsOfOtherClusters(t *testing.T) {
+	store := newTestStore(t)
+	before := importFixture(t, store, "before")
+	after := importFixture(t, store, "after")
+	if _, err := store.db.Exec("UPDATE importedmustgathers SET cluster = 'cluster-b' WHERE id = ?", after); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.DiffImports(before, after, nil); !errors.Is(err, ErrMixedClusters) {
+		t.Errorf("diffing the imports of two clusters returned %v, want %v", err, ErrMixedClusters)
+	}
+	// a store scoped to a cluster doesn't find the imports of the others
+	if _, err := store.InCluster(testCluster).DiffImports(before, after, nil); !IsNotFound(err) {
+		t.Errorf("diffing an import of another cluster returned %v, want not found", err)
+	}
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"logsviewer/pkg/backend/log"
+)
+
+// deleteBatchSize caps the uids bound to a single delete statement
+const deleteBatchSize = 500
+
+// snapshotTables tells the table and uid column of the objects of each
+// snapshot kind
+var snapshotTables = map[string][2]string{
+	"pods":          {"pods", "uuid"},
+	"vms":           {"vms", "uuid"},
+	"vmis":          {"vmis", "uuid"},
+	"nodes":         {"nodes", "systemUuid"},
+	"pvcs":          {"pvcs", "uuid"},
+	"migrations":    {"vmimigrations", "uuid"},
+	"subscriptions": {"subscriptions", "uuid"},
+	"csvs":          {"csvs", "uuid"},
+	"installplans":  {"installplans", "uuid"},
+}
+
+// DeleteImport deletes an import with its snapshots, the objects that were
+// only found in it and their events. Deleting the last import of a cluster
+// deletes every object and event of the cluster. The objects also found in
+// another import keep their row, it's stored back from their latest
+// remaining snapshot when the import held their latest state.
+func (d *DatabaseInstance) DeleteImport(id int) (*DeletedImport, error) {
+	img, err := d.getImport(id)
+	if err != nil {
+		return nil, err
+	}
+	deleted := &DeletedImport{Import: img, Objects: map[string]int{}, Restored: map[string]int{}, RemovedPodUIDs: []string{}}
+	deleted.Import.InsightsData = ""
+
+	removed, outdated, err := d.importObjects(id, deleted)
+	if err != nil {
+		return nil, err
+	}
+	var others int
+	if err := d.queryRow(newSelectQuery("select count(*) from importedmustgathers").
+		where("cluster = ?", img.Cluster).
+		where("id <> ?", id)).Scan(&others); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, 60*time.Second)
+	defer cancel()
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	removedUIDs := []string{}
+	for _, kind := range SnapshotKinds() {
+		table := snapshotTables[kind]
+		count, err := deleteIn(ctx, tx, "DELETE FROM "+table[0]+" WHERE cluster = ? AND "+table[1], img.Cluster, removed[kind])
+		if err != nil {
+			return nil, err
+		}
+		if others == 0 {
+			// the objects stored without a snapshot go with the cluster
+			result, err := tx.ExecContext(ctx, "DELETE FROM "+table[0]+" WHERE cluster = ?", img.Cluster)
+			if err != nil {
+				return nil, err
+			}
+			rest, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			count += int(rest)
+		}
+		if count > 0 {
+			deleted.Objects[kind] = count
+		}
+		removedUIDs = append(removedUIDs, removed[kind]...)
+	}
+
+	if others == 0 {
+		result, err := tx.ExecContext(ctx, "DELETE FROM events WHERE cluster = ?", img.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		events, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		deleted.Events = int(events)
+	} else if deleted.Events, err = deleteIn(ctx, tx, "DELETE FROM events WHERE cluster = ? AND involvedUid", img.Cluster, removedUIDs); err != nil {
+		return nil, err
+	}
+
+	for _, statement := range []string{"DELETE FROM snapshots WHERE importId = ?", "DELETE FROM importedmustgathers WHERE id = ?"} {
+		if _, err := tx.ExecContext(ctx, statement, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.restoreLatest(ctx, tx, img.Cluster, outdated, deleted); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	log.Log.Println("deleted import ", id, " of cluster ", img.Cluster, ": ", deleted.Objects, " and ", deleted.Events, " events, restored ", deleted.Restored)
+	return deleted, nil
+}
+
+// importObjects lists by kind the uids of the objects whose only snapshot
+// is the one of the import, recording the pods among them, and of the
+// objects also found in other imports whose latest snapshot is the one of
+// the import
+func (d *DatabaseInstance) importObjects(id int, deleted *DeletedImport) (map[string][]string, map[string][]string, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 30*time.Second)
+	defer cancel()
+
+	// the latest snapshot is ordered like StoreSnapshot does
+	query := newSelectQuery("select s.kind, s.uuid, "+
+		"(select count(*) from snapshots o where o.kind = s.kind and o.uuid = s.uuid and o.importId <> s.importId), "+
+		"(select count(*) from snapshots o where o.kind = s.kind and o.uuid = s.uuid and "+
+		"(o.gatherTime > s.gatherTime or (o.gatherTime = s.gatherTime and o.importId > s.importId))) from snapshots s").
+		where("s.importId = ?", id)
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	removed, outdated := map[string][]string{}, map[string][]string{}
+	for rows.Next() {
+		var kind, uid string
+		var others, later int
+		if err := rows.Scan(&kind, &uid, &others, &later); err != nil {
+			return nil, nil, err
+		}
+		if others > 0 {
+			if later == 0 {
+				outdated[kind] = append(outdated[kind], uid)
+			}
+			continue
+		}
+		removed[kind] = append(removed[kind], uid)
+		if kind == "pods" {
+			deleted.RemovedPodUIDs = append(deleted.RemovedPodUIDs, uid)
+		}
+	}
+	return removed, outdated, rows.Err()
+}
+
+// restoreLatest stores the objects back from their latest snapshot within
+// tx, once the snapshots of the deleted import are gone
+func (d *DatabaseInstance) restoreLatest(ctx context.Context, tx *sql.Tx, cluster string, outdated map[string][]string, deleted *DeletedImport) error {
+	restorer := &ObjectStore{storeDB: d.inTx(tx).InCluster(cluster), lockDBConn: &sync.Mutex{}}
+	for _, kind := range SnapshotKinds() {
+		for _, uid := range outdated[kind] {
+			var content []byte
+			statement, args := newSelectQuery("select content from snapshots").
+				where("kind = ?", kind).
+				where("uuid = ?", uid).
+				order("gatherTime DESC, importId DESC").
+				limited(1, 0)
+			if err := tx.QueryRowContext(ctx, statement, args...).Scan(&content); err != nil {
+				return err
+			}
+			if err := restorer.restore(kind, content); err != nil {
+				return fmt.Errorf("failed to restore the %s %s: %w", kind, uid, err)
+			}
+		}
+		if len(outdated[kind]) > 0 {
+			deleted.Restored[kind] = len(outdated[kind])
+		}
+	}
+	return nil
+}
+
+// deleteIn runs statement, ending with the column to match, against values
+// in batches and counts the rows deleted
+func deleteIn(ctx context.Context, tx *sql.Tx, statement string, cluster string, values []string) (int, error) {
+	count := 0
+	for start := 0; start < len(values); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(values) {
+			end = len(values)
+		}
+		batch := values[start:end]
+		result, err := tx.ExecContext(ctx, statement+" IN ("+placeholders(len(batch))+")", append([]interface{}{cluster}, toArgs(batch)...)...)
+		if err != nil {
+			return 0, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		count += int(deleted)
+	}
+	return count, nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// testNode is a node running an OS image
+func testNode(name string, osImage string) *k8sv1.Node {
+	node := &k8sv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	node.Status.NodeInfo.SystemUUID = name + "-uid"
+	node.Status.NodeInfo.OSImage = osImage
+	return node
+}
+
+func TestDeleteImport(t *testing.T) {
+	older := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+
+	tests := []struct {
+		name string
+		// deleteNewer deletes the import of the newer gather, the older one otherwise
+		deleteNewer  bool
+		phase        k8sv1.PodPhase
+		node         string
+		osImage      string
+		restored     map[string]int
+		removedPod   string
+		historyTimes []time.Time
+	}{
+		{
+			name:         "newer",
+			deleteNewer:  true,
+			phase:        k8sv1.PodPending,
+			node:         "node-a",
+			osImage:      "RHCOS 4.12",
+			restored:     map[string]int{"pods": 1, "nodes": 1},
+			removedPod:   "newer-only",
+			historyTimes: []time.Time{older},
+		},
+		{
+			name:         "older",
+			phase:        k8sv1.PodRunning,
+			node:         "node-b",
+			osImage:      "RHCOS 4.13",
+			restored:     map[string]int{},
+			removedPod:   "older-only",
+			historyTimes: []time.Time{newer},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			olderImport := importGather(t, store, "older.tar.gz", older,
+				testPod("pod-uid", k8sv1.PodPending, "node-a"), testPod("older-only", k8sv1.PodSucceeded, "node-a"), testNode("node-a", "RHCOS 4.12"))
+			newerImport := importGather(t, store, "newer.tar.gz", newer,
+				testPod("pod-uid", k8sv1.PodRunning, "node-b"), testPod("newer-only", k8sv1.PodRunning, "node-b"), testNode("node-a", "RHCOS 4.13"))
+
+			id := olderImport.ID
+			if tt.deleteNewer {
+				id = newerImport.ID
+			}
+			deleted, err := store.DeleteImport(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(deleted.Restored, tt.restored) {
+				t.Errorf("restored %v, want %v", deleted.Restored, tt.restored)
+			}
+			if !reflect.DeepEqual(deleted.RemovedPodUIDs, []string{tt.removedPod}) || !reflect.DeepEqual(deleted.Objects, map[string]int{"pods": 1}) {
+				t.Errorf("removed the pods %q and the objects %v, want only the pod %s", deleted.RemovedPodUIDs, deleted.Objects, tt.removedPod)
+			}
+
+			// the row holds the latest remaining state
+			pod, err := store.GetPodObject("pod-uid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pod.Status.Phase != tt.phase || pod.Spec.NodeName != tt.node {
+				t.Errorf("pod is %s on %s, want %s on %s", pod.Status.Phase, pod.Spec.NodeName, tt.phase, tt.node)
+			}
+			result, err := store.GetPods(1, -1, &GenericQueryDetails{UUID: "pod-uid"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows := result["data"].([]map[string]interface{}); len(rows) != 1 || rows[0]["phase"] != string(tt.phase) {
+				t.Errorf("listed %v, want the pod in phase %s", rows, tt.phase)
+			}
+			nodes, err := store.GetNodes(1, -1, &GenericQueryDetails{Name: "node-a"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rows := nodes["data"].([]map[string]interface{}); len(rows) != 1 || rows[0]["osImage"] != tt.osImage {
+				t.Errorf("listed the nodes %v, want node-a running %s", rows, tt.osImage)
+			}
+			if _, err := store.GetPodObject(tt.removedPod); !IsNotFound(err) {
+				t.Errorf("the pod %s only found in the deleted import is still there: %v", tt.removedPod, err)
+			}
+
+			history, err := store.GetObjectHistory("pods", "pod-uid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			times := []time.Time{}
+			for _, snapshot := range history {
+				times = append(times, snapshot.GatherTime)
+			}
+			if !reflect.DeepEqual(times, tt.historyTimes) {
+				t.Errorf("history gathered at %v, want %v", times, tt.historyTimes)
+			}
+		})
+	}
+}
+
+// testMigration is a migration of the VMI fedora to a node, and the VMI
+// telling its state
+func testMigration(phase kubevirtv1.VirtualMachineInstanceMigrationPhase, node string) (*kubevirtv1.VirtualMachineInstance, *kubevirtv1.VirtualMachineInstanceMigration) {
+	started := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	state := &kubevirtv1.VirtualMachineInstanceMigrationState{MigrationUID: "migration-uid", TargetNode: node, TargetPod: "virt-launcher-" + node,
+		StartTimestamp: &started, EndTimestamp: &started}
+	vmi := &kubevirtv1.VirtualMachineInstance{ObjectMeta: metav1.ObjectMeta{Name: "fedora", Namespace: "default", UID: "vmi-uid"}}
+	vmi.Status = kubevirtv1.VirtualMachineInstanceStatus{Phase: kubevirtv1.Running, NodeName: node, MigrationState: state}
+	migration := &kubevirtv1.VirtualMachineInstanceMigration{ObjectMeta: metav1.ObjectMeta{Name: "fedora-migration", Namespace: "default", UID: types.UID(state.MigrationUID)},
+		Spec: kubevirtv1.VirtualMachineInstanceMigrationSpec{VMIName: "fedora"}}
+	migration.Status = kubevirtv1.VirtualMachineInstanceMigrationStatus{Phase: phase, MigrationState: state}
+	return vmi, migration
+}
+
+func TestDeleteImportOfMigratedVMI(t *testing.T) {
+	older := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := newTestStore(t)
+	vmi, migration := testMigration(kubevirtv1.MigrationRunning, "node-a")
+	importGather(t, store, "older.tar.gz", older, vmi, migration)
+	vmi, migration = testMigration(kubevirtv1.MigrationSucceeded, "node-b")
+	newerImport := importGather(t, store, "newer.tar.gz", older.Add(time.Hour), vmi, migration)
+
+	// the VMI is restored within the transaction looking up its migration
+	deleted, err := store.DeleteImport(newerImport.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"vmis": 1, "migrations": 1}; !reflect.DeepEqual(deleted.Restored, want) {
+		t.Errorf("restored %v, want %v", deleted.Restored, want)
+	}
+	vmis, err := store.GetVmis(1, -1, &GenericQueryDetails{UUID: "vmi-uid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := vmis["data"].([]map[string]interface{}); len(rows) != 1 || rows[0]["nodeName"] != "node-a" {
+		t.Errorf("listed the VMIs %v, want fedora on node-a", rows)
+	}
+	restored, err := store.getSingleMigrationByUUID("migration-uid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Phase != string(kubevirtv1.MigrationRunning) || restored.TargetNode != "node-a" {
+		t.Errorf("migration is %s to %s, want Running to node-a", restored.Phase, restored.TargetNode)
+	}
+}
+
+func TestDeleteLastImportOfCluster(t *testing.T) {
+	store := newTestStore(t)
+	img := importGather(t, store, "only.tar.gz", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC),
+		testPod("pod-uid", k8sv1.PodRunning, "node-a"), testNode("node-a", "RHCOS 4.13"))
+
+	deleted, err := store.DeleteImport(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted.Restored) != 0 {
+		t.Errorf("restored %v, want nothing", deleted.Restored)
+	}
+	for _, kind := range []string{"pods", "nodes"} {
+		if deleted.Objects[kind] != 1 {
+			t.Errorf("deleted %v, want a single object of %s", deleted.Objects, kind)
+		}
+	}
+	if _, err := store.DeleteImport(img.ID); !IsNotFound(err) {
+		t.Errorf("deleting the import again returned %v, want not found", err)
+	}
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCursorExpired is returned for a cursor whose last row no longer exists
+var ErrCursorExpired = errors.New("the row the cursor points after no longer exists, start again from the first page")
+
+// listSpec tells which table an object list reads and the columns it
+// returns, the key is the primary key of the table, its columns listed as in
+// an upsertQuery
+type listSpec struct {
+	table   string
+	key     string
+	columns []string
+}
+
+var listSpecs = map[string]listSpec{
+	"pods": {table: "pods", key: "uuid",
+		columns: []string{"uuid", "name", "namespace", "phase", "activeContainers", "totalContainers", "creationTime", "createdBy"}},
+	// node names are only unique within a cluster
+	"nodes": {table: "nodes", key: "cluster, name",
+		columns: []string{"name", "systemUuid", "status", "internalIP", "hostName", "osImage", "kernelVersion", "kubletVersion", "containerRuntimeVersion", "cluster"}},
+	"vms": {table: "vms", key: "uuid",
+		columns: []string{"uuid", "name", "namespace", "running", "created", "ready", "status"}},
+	"vmis": {table: "vmis", key: "uuid",
+		columns: []string{"uuid", "name", "namespace", "phase", "reason", "nodeName", "creationTime"}},
+	"migrations": {table: "vmimigrations", key: "uuid",
+		columns: []string{"name", "namespace", "uuid", "phase", "vmiName", "targetPod", "creationTime", "endTimestamp", "sourceNode", "targetNode", "completed", "failed"}},
+	"pvcs": {table: "pvcs", key: "uuid",
+		columns: []string{"name", "namespace", "uuid", "reason", "phase", "accessModes", "storageClassName", "volumeName", "volumeMode", "capacity", "creationTime"}},
+	"subscriptions": {table: "subscriptions", key: "uuid",
+		columns: []string{"name", "namespace", "uuid", "source", "sourceNamespace", "startingCSV", "currentCSV", "installedCSV", "state", "creationTime", "content"}},
+}
+
+// ListKinds lists the kinds having an object list
+func ListKinds() []string {
+	return []string{"pods", "nodes", "vms", "vmis", "migrations", "pvcs", "subscriptions"}
+}
+
+// listQuery selects the list columns of kind, with extra columns appended
+func listQuery(kind string, extra ...string) *selectQuery {
+	spec := listSpecs[kind]
+	columns := append(append([]string{}, spec.columns...), extra...)
+	return newSelectQuery("select " + strings.Join(columns, ", ") + " from " + spec.table)
+}
+
+// ListSort orders an object list by one of its columns, ties are broken by
+// the primary key in the same direction
+type ListSort struct {
+	Column     string
+	Descending bool
+}
+
+func (s ListSort) String() string {
+	if s.Descending {
+		return s.Column + " desc"
+	}
+	return s.Column + " asc"
+}
+
+// defaultListSort orders the lists by name, rows are returned in a stable
+// order so that pages don't overlap
+var defaultListSort = ListSort{Column: "name"}
+
+// SortableColumns lists the columns a list of kind can be sorted by
+func SortableColumns(kind string) []string {
+	columns := []string{}
+	for _, column := range listSpecs[kind].columns {
+		if column != "content" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// ParseListSort parses a sort parameter such as "creationTime desc", the
+// direction defaults to ascending
+func ParseListSort(kind string, value string) (*ListSort, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("sort %q must be a column optionally followed by asc or desc", value)
+	}
+	sort := &ListSort{Column: fields[0]}
+	if !contains(SortableColumns(kind), sort.Column) {
+		return nil, fmt.Errorf("%s can't be sorted by %q, the columns are %s", kind, sort.Column, strings.Join(SortableColumns(kind), ", "))
+	}
+	if len(fields) == 2 {
+		switch strings.ToLower(fields[1]) {
+		case "asc":
+		case "desc":
+			sort.Descending = true
+		default:
+			return nil, fmt.Errorf("sort direction %q must be asc or desc", fields[1])
+		}
+	}
+	return sort, nil
+}
+
+// keyColumns lists the columns of the primary key of the list
+func (s listSpec) keyColumns() []string {
+	return strings.Split(s.key, ", ")
+}
+
+// keyOf renders the primary key of a row as a cursor position, the values of
+// a key of several columns are joined by slashes
+func (s listSpec) keyOf(row map[string]interface{}) string {
+	values := []string{}
+	for _, column := range s.keyColumns() {
+		values = append(values, fmt.Sprintf("%s", row[column]))
+	}
+	return strings.Join(values, "/")
+}
+
+// listCursor is the opaque position of a page, the primary key of the last
+// row of the previous page under a sort
+type listCursor struct {
+	Sort  string `json:"sort"`
+	After string `json:"after"`
+}
+
+func encodeCursor(sort ListSort, after string) string {
+	encoded, _ := json.Marshal(listCursor{Sort: sort.String(), After: after})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// ParseListCursor decodes a cursor returned as nextCursor, it is only valid
+// with the sort it was returned for
+func ParseListCursor(value string, sort *ListSort) (string, error) {
+	if sort == nil {
+		sort = &defaultListSort
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	cursor := listCursor{}
+	if err == nil {
+		err = json.Unmarshal(decoded, &cursor)
+	}
+	if err != nil || cursor.After == "" {
+		return "", fmt.Errorf("invalid cursor %q", value)
+	}
+	if cursor.Sort != sort.String() {
+		return "", fmt.Errorf("the cursor was returned for the sort %q, not %q", cursor.Sort, sort.String())
+	}
+	return cursor.After, nil
+}
+
+// getList returns a page of an object list in the genericGet format. The
+// rows are ordered by details.Sort, and start after details.After when
+// paging with a cursor. The meta holds the nextCursor of the following page
+// while there is one, the row count is only queried when paging by page
+// number and it can't be told from the page itself.
+func (d *DatabaseInstance) getList(kind string, query *selectQuery, page int, perPage int, details *GenericQueryDetails) (map[string]interface{}, error) {
+	spec := listSpecs[kind]
+	sort, after := defaultListSort, ""
+	if details != nil {
+		if details.Sort != nil {
+			sort = *details.Sort
+		}
+		after = details.After
+	}
+
+	direction, comparison := "ASC", ">"
+	if sort.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	keys := spec.keyColumns()
+	if after != "" {
+		// the last key column may hold slashes, the others don't
+		values := strings.SplitN(after, "/", len(keys))
+		if len(values) != len(keys) {
+			return nil, ErrCursorExpired
+		}
+		lastRow := func(column string) *selectQuery {
+			query := d.scoped(newSelectQuery("select " + column + " from " + spec.table))
+			for i, key := range keys {
+				query.where(key+" = ?", values[i])
+			}
+			return query
+		}
+		// the sort value is compared in the database, which keeps its own
+		// representation of times
+		var lastIsNull bool
+		err := d.queryRow(lastRow(sort.Column + " IS NULL")).Scan(&lastIsNull)
+		if err == sql.ErrNoRows {
+			return nil, ErrCursorExpired
+		} else if err != nil {
+			return nil, err
+		}
+		last := lastRow(sort.Column)
+		afterKey := "(" + spec.key + ") " + comparison + " (" + placeholders(len(keys)) + ")"
+		if lastIsNull {
+			query.where(sort.Column+" IS NULL AND "+afterKey, toArgs(values)...)
+		} else {
+			args := append(append(append([]interface{}{}, last.Args()...), last.Args()...), toArgs(values)...)
+			query.where(sort.Column+" IS NULL OR "+sort.Column+" "+comparison+" ("+last.String()+")"+
+				" OR ("+sort.Column+" = ("+last.String()+") AND "+afterKey+")", args...)
+		}
+		page = 1
+	}
+	// nulls come last in both directions
+	order := []string{sort.Column + " IS NULL", sort.Column + " " + direction}
+	for _, key := range keys {
+		order = append(order, key+" "+direction)
+	}
+	query.order(strings.Join(order, ", "))
+
+	// one more row tells whether there is a next page
+	statement, args := query.String(), query.Args()
+	if perPage != -1 {
+		statement, args = query.limited(perPage+1, (page-1)*perPage)
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+	rows, err := d.conn().QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data, err := scanRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := map[string]interface{}{"per_page": perPage}
+	hasNext := perPage != -1 && len(data) > perPage
+	if hasNext {
+		data = data[:perPage]
+		meta["nextCursor"] = encodeCursor(sort, spec.keyOf(data[perPage-1]))
+	}
+	if after != "" {
+		return map[string]interface{}{"data": data, "meta": meta}, nil
+	}
+
+	var counts map[string]int
+	switch {
+	case perPage == -1:
+		counts = pageMeta(page, perPage, len(data))
+	case !hasNext && (len(data) > 0 || page == 1):
+		counts = pageMeta(page, perPage, (page-1)*perPage+len(data))
+	default:
+		counts, err = d.getMeta(page, perPage, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range counts {
+		meta[key] = value
+	}
+	return map[string]interface{}{"data": data, "meta": meta}, nil
+}
@@ -0,0 +1,327 @@
+package db
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseListSort(t *testing.T) {
+	tests := []struct {
+		kind  string
+		value string
+		want  *ListSort
+	}{
+		{kind: "pods", value: "name", want: &ListSort{Column: "name"}},
+		{kind: "pods", value: "creationTime desc", want: &ListSort{Column: "creationTime", Descending: true}},
+		{kind: "pods", value: "  phase   ASC ", want: &ListSort{Column: "phase"}},
+		{kind: "nodes", value: "kubletVersion DESC", want: &ListSort{Column: "kubletVersion", Descending: true}},
+		{kind: "migrations", value: "vmiName", want: &ListSort{Column: "vmiName"}},
+		{kind: "pods", value: ""},
+		{kind: "pods", value: "name desc extra"},
+		{kind: "pods", value: "name sideways"},
+		// column names are matched exactly, never interpreted
+		{kind: "pods", value: "Name"},
+		{kind: "pods", value: "nodeName"},
+		{kind: "pods", value: "content"},
+		{kind: "subscriptions", value: "content"},
+		{kind: "pods", value: "name;DROP"},
+		{kind: "pods", value: "(select 1)"},
+		{kind: "unknown", value: "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.value, func(t *testing.T) {
+			sort, err := ParseListSort(tt.kind, tt.value)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("ParseListSort(%q, %q) = %v, want an error", tt.kind, tt.value, sort)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(sort, tt.want) {
+				t.Errorf("ParseListSort(%q, %q) = %v, %v, want %v", tt.kind, tt.value, sort, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortableColumns(t *testing.T) {
+	for _, kind := range ListKinds() {
+		columns := SortableColumns(kind)
+		if len(columns) == 0 {
+			t.Errorf("%s has no sortable column", kind)
+		}
+		if contains(columns, "content") {
+			t.Errorf("%s can be sorted by content", kind)
+		}
+		for _, column := range columns {
+			if _, err := ParseListSort(kind, column+" desc"); err != nil {
+				t.Errorf("%s can't be sorted by its sortable column %s: %v", kind, column, err)
+			}
+		}
+	}
+}
+
+func TestParseListCursor(t *testing.T) {
+	byCreation := &ListSort{Column: "creationTime", Descending: true}
+	forge := func(content string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(content))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+		sort   *ListSort
+		want   string
+	}{
+		{name: "default sort", cursor: encodeCursor(defaultListSort, "uid-1"), want: "uid-1"},
+		{name: "same sort", cursor: encodeCursor(*byCreation, "uid-2"), sort: byCreation, want: "uid-2"},
+		{name: "hostile key", cursor: encodeCursor(defaultListSort, `'; DROP TABLE pods; --`), want: `'; DROP TABLE pods; --`},
+		{name: "other sort", cursor: encodeCursor(defaultListSort, "uid-1"), sort: byCreation},
+		{name: "other direction", cursor: encodeCursor(ListSort{Column: "creationTime"}, "uid-1"), sort: byCreation},
+		{name: "empty", cursor: ""},
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"sort":"name asc","after":"uid-1"}`)) + "="},
+		{name: "not json", cursor: forge("uid-1")},
+		{name: "no key", cursor: forge(`{"sort":"name asc"}`)},
+		{name: "empty key", cursor: forge(`{"sort":"name asc","after":""}`)},
+		{name: "forged sort", cursor: forge(`{"sort":"name asc, (select 1)","after":"uid-1"}`)},
+		{name: "key of another type", cursor: forge(`{"sort":"name asc","after":1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, err := ParseListCursor(tt.cursor, tt.sort)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("ParseListCursor(%q) = %q, want an error", tt.cursor, after)
+				}
+				return
+			}
+			if err != nil || after != tt.want {
+				t.Errorf("ParseListCursor(%q) = %q, %v, want %q", tt.cursor, after, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageMeta(t *testing.T) {
+	tests := []struct {
+		page, perPage, total int
+		want                 map[string]int
+	}{
+		{page: 1, perPage: -1, total: 0, want: map[string]int{"page": 1, "per_page": -1, "totalRowCount": 0, "totalPages": 1}},
+		{page: 1, perPage: -1, total: 7, want: map[string]int{"page": 1, "per_page": -1, "totalRowCount": 7, "totalPages": 1}},
+		{page: 1, perPage: 10, total: 0, want: map[string]int{"page": 1, "per_page": 10, "totalRowCount": 0, "totalPages": 0}},
+		{page: 1, perPage: 10, total: 10, want: map[string]int{"page": 1, "per_page": 10, "totalRowCount": 10, "totalPages": 1}},
+		{page: 2, perPage: 10, total: 11, want: map[string]int{"page": 2, "per_page": 10, "totalRowCount": 11, "totalPages": 2}},
+		{page: 5, perPage: 3, total: 7, want: map[string]int{"page": 5, "per_page": 3, "totalRowCount": 7, "totalPages": 3}},
+	}
+	for _, tt := range tests {
+		if meta := pageMeta(tt.page, tt.perPage, tt.total); !reflect.DeepEqual(meta, tt.want) {
+			t.Errorf("pageMeta(%d, %d, %d) = %v, want %v", tt.page, tt.perPage, tt.total, meta, tt.want)
+		}
+	}
+}
+
+// storeListPods stores a pod per name, created an hour apart in the order
+// of their last character
+func storeListPods(t *testing.T, store *DatabaseInstance, phase string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(name[len(name)-1]) * time.Hour)
+		pod := &Pod{Name: name, Namespace: "default", UUID: "uid-" + name, Phase: phase, CreationTime: metav1.NewTime(created),
+			Content: testContent(t, testMetadata(name, "default", "uid-"+name))}
+		if err := store.StorePod(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// nextPage lists a page of pods after cursor, and returns its names and the
+// cursor of the next one
+func nextPage(t *testing.T, store *DatabaseInstance, sort *ListSort, cursor string, perPage int) ([]string, string) {
+	t.Helper()
+	details := &GenericQueryDetails{Sort: sort}
+	if cursor != "" {
+		after, err := ParseListCursor(cursor, sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		details.After = after
+	}
+	result, err := store.GetPods(1, perPage, details)
+	names := listNames(t, result, err, "name")
+	next, _ := result["meta"].(map[string]interface{})["nextCursor"].(string)
+	return names, next
+}
+
+func TestListCursorAcrossInserts(t *testing.T) {
+	tests := []struct {
+		name string
+		sort *ListSort
+		// initial are stored before the first page, inserted after it
+		initial, inserted []string
+		want              []string
+	}{
+		{
+			name:     "by name",
+			initial:  []string{"pod-b", "pod-d", "pod-f", "pod-h", "pod-j"},
+			inserted: []string{"pod-a", "pod-c", "pod-e", "pod-i"},
+			// pod-a and pod-c sort before the cursor and are never listed
+			want: []string{"pod-b", "pod-d", "pod-e", "pod-f", "pod-h", "pod-i", "pod-j"},
+		},
+		{
+			name:     "by creation descending",
+			sort:     &ListSort{Column: "creationTime", Descending: true},
+			initial:  []string{"pod-j", "pod-h", "pod-f", "pod-d", "pod-b"},
+			inserted: []string{"pod-k", "pod-i", "pod-e", "pod-a"},
+			want:     []string{"pod-j", "pod-h", "pod-f", "pod-e", "pod-d", "pod-b", "pod-a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			storeListPods(t, store, "Running", tt.initial...)
+
+			names, cursor := nextPage(t, store, tt.sort, "", 2)
+			storeListPods(t, store, "Running", tt.inserted...)
+			for cursor != "" {
+				var page []string
+				page, cursor = nextPage(t, store, tt.sort, cursor, 2)
+				names = append(names, page...)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("listed %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestListCursorBreaksTiesByKey(t *testing.T) {
+	store := newTestStore(t)
+	names := []string{}
+	for i := 0; i < 7; i++ {
+		names = append(names, fmt.Sprintf("pod-%d", i))
+	}
+	// the whole list shares the sort value
+	storeListPods(t, store, "Pending", names...)
+
+	for _, sort := range []*ListSort{{Column: "phase"}, {Column: "phase", Descending: true}} {
+		t.Run(sort.String(), func(t *testing.T) {
+			listed, cursor := nextPage(t, store, sort, "", 3)
+			for cursor != "" {
+				var page []string
+				page, cursor = nextPage(t, store, sort, cursor, 3)
+				listed = append(listed, page...)
+			}
+			want := append([]string{}, names...)
+			if sort.Descending {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
+			}
+			if !reflect.DeepEqual(listed, want) {
+				t.Errorf("listed %q, want %q", listed, want)
+			}
+		})
+	}
+}
+
+func TestListCursorExpired(t *testing.T) {
+	store := newTestStore(t)
+	storeListPods(t, store, "Running", "pod-a", "pod-b", "pod-c")
+
+	_, cursor := nextPage(t, store, nil, "", 2)
+	after, err := ParseListCursor(cursor, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.db.Exec("DELETE FROM pods WHERE uuid = ?", after); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetPods(1, 2, &GenericQueryDetails{After: after}); err != ErrCursorExpired {
+		t.Errorf("listing after the deleted %q returned %v, want %v", after, err, ErrCursorExpired)
+	}
+
+	// a forged key is never found either
+	if _, err := store.GetPods(1, 2, &GenericQueryDetails{After: `uid-pod-a' OR '1'='1`}); err != ErrCursorExpired {
+		t.Errorf("listing after a forged key returned %v, want %v", err, ErrCursorExpired)
+	}
+}
+
+func TestListUnknownSortColumn(t *testing.T) {
+	_, err := ParseListSort("pods", "nodeName desc")
+	if err == nil {
+		t.Fatal("pods can be sorted by nodeName")
+	}
+	// the error tells the columns to pick from
+	for _, column := range SortableColumns("pods") {
+		if !strings.Contains(err.Error(), column) {
+			t.Errorf("%q doesn't list the column %s", err, column)
+		}
+	}
+}
+
+func TestListCursorAcrossClusters(t *testing.T) {
+	store := newTestStore(t)
+	// the nodes of both clusters share their names and OS image
+	for _, cluster := range []string{"cluster-a", "cluster-b"} {
+		for _, name := range []string{"node-a", "node-b", "node-c"} {
+			node := &Node{Name: name, SystemUUID: cluster + "-" + name, OsImage: "RHCOS 4.13", Content: testContent(t, testNode(name, "RHCOS 4.13"))}
+			if err := store.InCluster(cluster).(*DatabaseInstance).StoreNode(node); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, sort := range []*ListSort{nil, {Column: "osImage"}, {Column: "name", Descending: true}} {
+		name := "default"
+		if sort != nil {
+			name = sort.String()
+		}
+		t.Run(name, func(t *testing.T) {
+			listed := []string{}
+			cursor := ""
+			for {
+				details := &GenericQueryDetails{Sort: sort}
+				if cursor != "" {
+					after, err := ParseListCursor(cursor, sort)
+					if err != nil {
+						t.Fatal(err)
+					}
+					details.After = after
+				}
+				result, err := store.GetNodes(1, 1, details)
+				listed = append(listed, listNames(t, result, err, "systemUuid")...)
+				if cursor, _ = result["meta"].(map[string]interface{})["nextCursor"].(string); cursor == "" {
+					break
+				}
+			}
+			want := []string{"cluster-a-node-a", "cluster-b-node-a", "cluster-a-node-b", "cluster-b-node-b", "cluster-a-node-c", "cluster-b-node-c"}
+			switch {
+			case sort == nil:
+			case sort.Column == "osImage":
+				// the ties are broken by cluster and name
+				want = []string{"cluster-a-node-a", "cluster-a-node-b", "cluster-a-node-c", "cluster-b-node-a", "cluster-b-node-b", "cluster-b-node-c"}
+			default:
+				want = []string{"cluster-b-node-c", "cluster-a-node-c", "cluster-b-node-b", "cluster-a-node-b", "cluster-b-node-a", "cluster-a-node-a"}
+			}
+			if !reflect.DeepEqual(listed, want) {
+				t.Errorf("listed %q, want %q", listed, want)
+			}
+		})
+	}
+
+	// the page counts cover the nodes of both clusters as well
+	result, err := store.GetNodes(2, 2, nil)
+	if names := listNames(t, result, err, "systemUuid"); !reflect.DeepEqual(names, []string{"cluster-a-node-b", "cluster-b-node-b"}) {
+		t.Errorf("listed %q on the second page, want node-b of both clusters", names)
+	}
+	if pages := result["meta"].(map[string]interface{})["totalPages"]; pages != 3 {
+		t.Errorf("%v pages, want 3", pages)
+	}
+}
@@ -0,0 +1,378 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"logsviewer/pkg/backend/log"
+)
+
+// migration is a single forward-only schema change.
+// Migrations are applied in order, each exactly once, and recorded in the
+// schema_version table. Released migrations must never be edited; append a
+// new one instead.
+type migration struct {
+	version     int
+	description string
+	statements  func(d dialect) []string
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "initial schema",
+		// IF NOT EXISTS lets instances created before schema versioning adopt this version
+		statements: func(d dialect) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS pods (
+				  keyid varchar(100),
+				  kind varchar(100),
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  phase varchar(100),
+				  activeContainers TINYINT,
+				  totalContainers TINYINT,
+				  nodeName varchar(100),
+				  creationTime datetime,
+				  pvcs varchar(200),
+				  content json,
+				  createdBy varchar(100),
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS vms (
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  running varchar(100),
+				  created varchar(100),
+				  ready varchar(100),
+				  status varchar(100),
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS vmis (
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  reason varchar(100),
+				  phase varchar(100),
+				  nodeName varchar(100),
+				  creationTime datetime,
+				  content json,
+				  createdBy varchar(100),
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS vmimigrations (
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  phase varchar(100),
+				  vmiName varchar(100),
+				  targetPod varchar(100),
+				  creationTime datetime,
+				  endTimestamp datetime,
+				  sourceNode varchar(100),
+				  targetNode varchar(100),
+				  completed BOOLEAN,
+				  failed BOOLEAN,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS nodes (
+				  name varchar(100),
+				  systemUuid varchar(100),
+				  status varchar(100),
+				  internalIP varchar(100),
+				  hostName varchar(100),
+				  osImage varchar(100),
+				  kernelVersion varchar(100),
+				  kubletVersion varchar(100),
+				  containerRuntimeVersion varchar(100),
+				  content json,
+				  PRIMARY KEY (name)
+				);`,
+				`CREATE TABLE IF NOT EXISTS pvcs (
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  reason varchar(100),
+				  phase varchar(100),
+				  accessModes varchar(100),
+				  storageClassName varchar(100),
+				  volumeName varchar(100),
+				  volumeMode varchar(100),
+				  capacity varchar(100),
+				  creationTime datetime,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS subscriptions (
+				  name varchar(100),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  source varchar(100),
+				  sourceNamespace varchar(100),
+				  startingCSV varchar(100),
+				  currentCSV varchar(100),
+				  installedCSV varchar(100),
+				  state varchar(100),
+				  creationTime datetime,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS importedmustgathers (
+				  name varchar(200),
+				  importTime datetime,
+				  gatherTime datetime,
+				  insightsData longblob,
+				  ` + d.autoIncrementID() + `
+				);`,
+			}
+		},
+	},
+	{
+		version:     2,
+		description: "noise filters",
+		statements: func(d dialect) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS noisefilters (
+				  phrase varchar(1000) NOT NULL,
+				  description varchar(200),
+				  enabled BOOLEAN NOT NULL,
+				  ` + d.autoIncrementID() + `
+				);`,
+				// the message every correlation query used to exclude
+				`INSERT INTO noisefilters (phrase, description, enabled) VALUES (
+				  'certificate with common name ''kubevirt.io:system:client:virt-handler'' retrieved.',
+				  'virt-handler client certificate reloads',
+				  1
+				);`,
+			}
+		},
+	},
+	{
+		version:     3,
+		description: "cluster service versions and install plans",
+		statements: func(d dialect) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS csvs (
+				  name varchar(200),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  version varchar(100),
+				  phase varchar(100),
+				  replaces varchar(200),
+				  creationTime datetime,
+				  lastTransitionTime datetime,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+				`CREATE TABLE IF NOT EXISTS installplans (
+				  name varchar(200),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  phase varchar(100),
+				  csvNames varchar(1000),
+				  approved BOOLEAN,
+				  creationTime datetime,
+				  lastTransitionTime datetime,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+			}
+		},
+	},
+	{
+		version:     4,
+		description: "events",
+		statements: func(d dialect) []string {
+			return []string{
+				`CREATE TABLE IF NOT EXISTS events (
+				  name varchar(200),
+				  namespace varchar(100),
+				  uuid varchar(100),
+				  involvedKind varchar(100),
+				  involvedName varchar(200),
+				  involvedUid varchar(100),
+				  type varchar(100),
+				  reason varchar(200),
+				  message text,
+				  count int,
+				  firstTimestamp datetime,
+				  lastTimestamp datetime,
+				  content json,
+				  PRIMARY KEY (uuid)
+				);`,
+			}
+		},
+	},
+	{
+		version:     5,
+		description: "object snapshots",
+		statements: func(d dialect) []string {
+			statements := []string{
+				`CREATE TABLE IF NOT EXISTS snapshots (
+				  kind varchar(50),
+				  uuid varchar(100),
+				  importId int,
+				  name varchar(200),
+				  namespace varchar(100),
+				  gatherTime datetime,
+				  content json,
+				  PRIMARY KEY (kind, uuid, importId)
+				);`,
+			}
+			// the objects stored so far kept the state of the first import they were found in
+			for _, object := range [][4]string{
+				// kind, table, uid and namespace columns
+				{"pods", "pods", "uuid", "namespace"},
+				{"vms", "vms", "uuid", "namespace"},
+				{"vmis", "vmis", "uuid", "namespace"},
+				{"nodes", "nodes", "systemUuid", "''"},
+				{"pvcs", "pvcs", "uuid", "namespace"},
+				{"migrations", "vmimigrations", "uuid", "namespace"},
+				{"subscriptions", "subscriptions", "uuid", "namespace"},
+				{"csvs", "csvs", "uuid", "namespace"},
+				{"installplans", "installplans", "uuid", "namespace"},
+			} {
+				statements = append(statements,
+					`INSERT INTO snapshots (kind, uuid, importId, name, namespace, gatherTime, content)
+					  SELECT '`+object[0]+`', `+object[2]+`, img.id, name, `+object[3]+`, img.gatherTime, content
+					  FROM `+object[1]+`, (SELECT id, gatherTime FROM importedmustgathers ORDER BY id LIMIT 1) img
+					  WHERE `+object[2]+` <> '';`)
+			}
+			return statements
+		},
+	},
+	{
+		version:     6,
+		description: "clusters",
+		statements: func(d dialect) []string {
+			// the gathers imported so far didn't record their cluster
+			statements := []string{
+				`ALTER TABLE importedmustgathers ADD COLUMN clusterName varchar(200);`,
+			}
+			for _, table := range []string{"importedmustgathers", "pods", "vms", "vmis", "vmimigrations", "pvcs", "subscriptions", "csvs", "installplans", "events"} {
+				statements = append(statements, `ALTER TABLE `+table+` ADD COLUMN cluster varchar(100) NOT NULL DEFAULT 'unknown';`)
+			}
+			// node names are only unique within a cluster, the table is
+			// rebuilt as neither engine changes a primary key the same way
+			return append(statements,
+				`CREATE TABLE nodes_by_cluster (
+				  cluster varchar(100) NOT NULL DEFAULT 'unknown',
+				  name varchar(100) NOT NULL,
+				  systemUuid varchar(100),
+				  status varchar(100),
+				  internalIP varchar(100),
+				  hostName varchar(100),
+				  osImage varchar(100),
+				  kernelVersion varchar(100),
+				  kubletVersion varchar(100),
+				  containerRuntimeVersion varchar(100),
+				  content json,
+				  PRIMARY KEY (cluster, name)
+				);`,
+				`INSERT INTO nodes_by_cluster (name, systemUuid, status, internalIP, hostName, osImage, kernelVersion, kubletVersion, containerRuntimeVersion, content)
+				  SELECT name, systemUuid, status, internalIP, hostName, osImage, kernelVersion, kubletVersion, containerRuntimeVersion, content FROM nodes;`,
+				`DROP TABLE nodes;`,
+				`ALTER TABLE nodes_by_cluster RENAME TO nodes;`,
+			)
+		},
+	},
+	{
+		version:     7,
+		description: "default noise filters",
+		// seeded disabled so the existing correlation results don't change,
+		// they're enabled through the noisefilters API
+		statements: func(d dialect) []string {
+			return []string{
+				`INSERT INTO noisefilters (phrase, description, enabled) VALUES
+				  ('forcing resync', 'informer reflector resyncs', 0),
+				  ('attempting to acquire leader lease', 'leader election attempts of the standby replicas', 0),
+				  ('Heartbeat sent', 'virt-handler node heartbeats', 0);`,
+			}
+		},
+	},
+}
+
+const createSchemaVersionTable = `
+    CREATE TABLE IF NOT EXISTS schema_version (
+      version int,
+      description varchar(200),
+      appliedAt datetime,
+      PRIMARY KEY (version)
+    );
+    `
+
+// migrate brings the schema up to the latest known version
+func (d *DatabaseInstance) migrate() error {
+	if err := d.execTable(createSchemaVersionTable); err != nil {
+		return fmt.Errorf("failed to create the schema_version table: %w", err)
+	}
+
+	current, err := d.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than the latest version %d supported by this logsviewer, refusing to start", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		log.Log.Printf("applying schema migration %d: %s", m.version, m.description)
+		if err := d.applyMigration(m); err != nil {
+			return fmt.Errorf("failed to apply schema migration %d (%s), the database is left at version %d: %w", m.version, m.description, current, err)
+		}
+		current = m.version
+	}
+
+	return nil
+}
+
+func (d *DatabaseInstance) schemaVersion() (int, error) {
+	var version sql.NullInt64
+	err := d.db.QueryRow("select max(version) from schema_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+func (d *DatabaseInstance) applyMigration(m migration) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Minute)
+	defer cancel()
+
+	// MySQL commits DDL implicitly, so the transaction only guarantees that the
+	// version is recorded together with the last statement.
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements(d.dialect) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_version(version, description, appliedAt) values (?, ?, ?)",
+		m.version,
+		m.description,
+		time.Now().UTC().Format("2006-01-02 15:04:05.999999"),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// MySQLOptions holds the connection details of a MySQL server
+type MySQLOptions struct {
+	Username string
+	Password string
+	Host     string
+	Port     string
+	DBName   string
+}
+
+type mysqlDialect struct {
+	opts MySQLOptions
+}
+
+// NewMySQLDatabaseInstance connects to a MySQL server
+func NewMySQLDatabaseInstance(opts MySQLOptions) (*DatabaseInstance, error) {
+	return newDatabaseInstance(&mysqlDialect{opts: opts})
+}
+
+func (m *mysqlDialect) driverName() string {
+	return MySQLDriver
+}
+
+func (m *mysqlDialect) dataSourceName() string {
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", m.opts.Username, m.opts.Password, net.JoinHostPort(m.opts.Host, m.opts.Port), m.opts.DBName)
+}
+
+func (m *mysqlDialect) configure(db *sql.DB) {}
+
+func (m *mysqlDialect) upsert(q upsertQuery) string {
+	if q.key == "" {
+		return q.insertPrefix() + ";"
+	}
+	if len(q.update) == 0 {
+		// MySQL has no DO NOTHING, assigning a key column to itself keeps
+		// the existing row without ignoring the other errors
+		column := strings.Split(q.key, ", ")[0]
+		return q.insertPrefix() + " ON DUPLICATE KEY UPDATE " + column + "=" + column + ";"
+	}
+	updates := []string{}
+	for _, column := range q.update {
+		updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", column, column))
+	}
+	return q.insertPrefix() + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ") + ";"
+}
+
+func (m *mysqlDialect) autoIncrementID() string {
+	return "id int(16) auto_increment, PRIMARY KEY (id)"
+}
@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// namespaceWarningsLimit caps the warning events listed in a namespace summary
+const namespaceWarningsLimit = 20
+
+var (
+	healthyVMStatuses  = []string{"Running", "Stopped", "Migrating", "Paused"}
+	healthyVMIPhases   = []string{"Running", "Succeeded"}
+	healthyPVCPhases   = []string{"Bound"}
+	healthyMigrations  = []string{"Pending", "Scheduling", "Scheduled", "PreparingTarget", "TargetReady", "Running", "WaitingForSync", "Succeeded"}
+	completedPodPhases = []string{"Succeeded"}
+)
+
+// GetNamespaceSummary counts the VMs, VMIs, pods, PVCs, migrations and
+// events of a namespace by status, and lists the unhealthy ones
+func (d *DatabaseInstance) GetNamespaceSummary(namespace string) (*NamespaceSummary, error) {
+	summary := &NamespaceSummary{Namespace: namespace, Healthy: true}
+
+	statusOf := func(healthy []string) func(rows *sql.Rows) (ObjectHealth, error) {
+		return func(rows *sql.Rows) (ObjectHealth, error) {
+			object := ObjectHealth{}
+			var status, reason sql.NullString
+			if err := rows.Scan(&object.UUID, &object.Name, &status, &reason); err != nil {
+				return object, err
+			}
+			object.Status = status.String
+			object.Reason = reason.String
+			object.Healthy = contains(healthy, object.Status)
+			return object, nil
+		}
+	}
+
+	var err error
+	summary.VMs, err = d.kindHealth(d.scoped(newSelectQuery("select uuid, name, status, '' from vms")).where("namespace = ?", namespace),
+		statusOf(healthyVMStatuses))
+	if err != nil {
+		return nil, err
+	}
+	summary.VMIs, err = d.kindHealth(d.scoped(newSelectQuery("select uuid, name, phase, reason from vmis")).where("namespace = ?", namespace),
+		statusOf(healthyVMIPhases))
+	if err != nil {
+		return nil, err
+	}
+	summary.PVCs, err = d.kindHealth(d.scoped(newSelectQuery("select uuid, name, phase, reason from pvcs")).where("namespace = ?", namespace),
+		statusOf(healthyPVCPhases))
+	if err != nil {
+		return nil, err
+	}
+	summary.Migrations, err = d.kindHealth(d.scoped(newSelectQuery("select uuid, name, phase, '' from vmimigrations")).where("namespace = ?", namespace),
+		statusOf(healthyMigrations))
+	if err != nil {
+		return nil, err
+	}
+	// a running pod is only healthy while all of its containers are running
+	summary.Pods, err = d.kindHealth(d.scoped(newSelectQuery("select uuid, name, phase, activeContainers, totalContainers from pods")).where("namespace = ?", namespace),
+		func(rows *sql.Rows) (ObjectHealth, error) {
+			object := ObjectHealth{}
+			var phase sql.NullString
+			var active, total sql.NullInt64
+			if err := rows.Scan(&object.UUID, &object.Name, &phase, &active, &total); err != nil {
+				return object, err
+			}
+			object.Status = phase.String
+			object.Healthy = contains(completedPodPhases, object.Status) ||
+				(object.Status == "Running" && active.Int64 == total.Int64)
+			if object.Status == "Running" && !object.Healthy {
+				object.Reason = fmt.Sprintf("%d/%d containers running", active.Int64, total.Int64)
+			}
+			return object, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Events, err = d.namespaceEvents(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	total := summary.Events.Total
+	for _, kind := range []KindHealth{summary.VMs, summary.VMIs, summary.Pods, summary.PVCs, summary.Migrations} {
+		total += kind.Total
+		if len(kind.Unhealthy) > 0 {
+			summary.Healthy = false
+		}
+	}
+	if total == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return summary, nil
+}
+
+// kindHealth counts the objects selected by query by status, scan reads a
+// row and tells whether the object is healthy
+func (d *DatabaseInstance) kindHealth(query *selectQuery, scan func(rows *sql.Rows) (ObjectHealth, error)) (KindHealth, error) {
+	health := KindHealth{ByStatus: map[string]int{}, Unhealthy: []ObjectHealth{}}
+
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+	rows, err := d.conn().QueryContext(ctx, query.order("name ASC").String(), query.Args()...)
+	if err != nil {
+		return health, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		object, err := scan(rows)
+		if err != nil {
+			return health, err
+		}
+		health.Total++
+		health.ByStatus[object.Status]++
+		if !object.Healthy {
+			health.Unhealthy = append(health.Unhealthy, object)
+		}
+	}
+	return health, rows.Err()
+}
+
+func (d *DatabaseInstance) namespaceEvents(namespace string) (EventsSummary, error) {
+	summary := EventsSummary{ByType: map[string]int{}, Warnings: []Event{}}
+
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	query := d.scoped(newSelectQuery("select type, count(*) from events")).where("namespace = ?", namespace)
+	rows, err := d.conn().QueryContext(ctx, query.String()+" group by type", query.Args()...)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventType sql.NullString
+		var count int
+		if err := rows.Scan(&eventType, &count); err != nil {
+			return summary, err
+		}
+		summary.Total += count
+		summary.ByType[eventType.String] = count
+	}
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+
+	summary.Warnings, err = d.queryEvents(d.scoped(newSelectQuery(eventColumns)).
+		where("namespace = ?", namespace).
+		where("type = ?", "Warning").
+		order("lastTimestamp DESC"), namespaceWarningsLimit)
+	return summary, err
+}
+
+const eventColumns = "select uuid, name, namespace, involvedKind, involvedName, involvedUid, type, reason, message, count, firstTimestamp, lastTimestamp from events"
+
+// queryEvents runs a query selecting eventColumns, the content is left out.
+// A limit of -1 returns every event.
+func (d *DatabaseInstance) queryEvents(query *selectQuery, limit int) ([]Event, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	statement, args := query.paginated(1, limit)
+	rows, err := d.conn().QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		event := Event{}
+		var kind, name, uid, eventType, reason, message sql.NullString
+		var count sql.NullInt64
+		var first, last sql.NullTime
+		if err := rows.Scan(&event.UUID, &event.Name, &event.Namespace, &kind, &name, &uid, &eventType, &reason, &message, &count, &first, &last); err != nil {
+			return nil, err
+		}
+		event.InvolvedKind = kind.String
+		event.InvolvedName = name.String
+		event.InvolvedUID = uid.String
+		event.Type = eventType.String
+		event.Reason = reason.String
+		event.Message = message.String
+		event.Count = int(count.Int64)
+		event.FirstTimestamp.Time = first.Time
+		event.LastTimestamp.Time = last.Time
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetNamespaceQueryParams collects the pods and VMIs of a namespace, and the
+// virt-handlers of the nodes its pods ran on
+func (d *DatabaseInstance) GetNamespaceQueryParams(namespace string) (QueryResults, error) {
+	results := QueryResults{Namespace: namespace}
+
+	pods, err := d.queryPods(d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods")).
+		where("namespace = ?", namespace).
+		order("creationTime ASC"))
+	if err != nil {
+		return results, err
+	}
+	vmiUUIDs, err := d.queryStrings(d.scoped(newSelectQuery("select uuid from vmis")).where("namespace = ?", namespace))
+	if err != nil {
+		return results, err
+	}
+	if len(pods) == 0 && len(vmiUUIDs) == 0 {
+		return results, sql.ErrNoRows
+	}
+	results.InvolvedPods = pods
+	results.VMIUUIDs = vmiUUIDs
+
+	if len(pods) > 0 {
+		err := d.queryRow(d.scoped(newSelectQuery("select creationTime from pods")).
+			where("namespace = ?", namespace).
+			order("creationTime ASC")).Scan(&results.StartTimestamp)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	nodes := []string{}
+	for _, pod := range pods {
+		if pod.NodeName != "" && !contains(nodes, pod.NodeName) {
+			nodes = append(nodes, pod.NodeName)
+		}
+	}
+	results.InvolvedVirtHandlers, err = d.queryPods(d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods")).
+		where("name like ?", "virt-handler%").
+		whereIn("nodeName", nodes))
+	if err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// queryStrings runs a query selecting a single string column
+func (d *DatabaseInstance) queryStrings(query *selectQuery) ([]string, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+func (d *DatabaseInstance) ListNoiseFilters() ([]NoiseFilter, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	query := newSelectQuery("select id, phrase, description, enabled from noisefilters").order("id")
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filters := []NoiseFilter{}
+	for rows.Next() {
+		filter := NoiseFilter{}
+		var description sql.NullString
+		if err := rows.Scan(&filter.ID, &filter.Phrase, &description, &filter.Enabled); err != nil {
+			return nil, err
+		}
+		filter.Description = description.String
+		filters = append(filters, filter)
+	}
+	return filters, rows.Err()
+}
+
+func (d *DatabaseInstance) GetNoiseFilter(id int) (*NoiseFilter, error) {
+	query := newSelectQuery("select id, phrase, description, enabled from noisefilters").
+		where("id = ?", id)
+
+	filter := &NoiseFilter{}
+	var description sql.NullString
+	if err := d.queryRow(query).Scan(&filter.ID, &filter.Phrase, &description, &filter.Enabled); err != nil {
+		return nil, err
+	}
+	filter.Description = description.String
+	return filter, nil
+}
+
+func (d *DatabaseInstance) CreateNoiseFilter(filter *NoiseFilter) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	result, err := d.conn().ExecContext(ctx, "INSERT INTO noisefilters (phrase, description, enabled) VALUES (?, ?, ?)",
+		filter.Phrase, filter.Description, filter.Enabled)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	filter.ID = int(id)
+	return nil
+}
+
+// UpdateNoiseFilter replaces the filter with the same id
+func (d *DatabaseInstance) UpdateNoiseFilter(filter *NoiseFilter) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	_, err := d.conn().ExecContext(ctx, "UPDATE noisefilters SET phrase = ?, description = ?, enabled = ? WHERE id = ?",
+		filter.Phrase, filter.Description, filter.Enabled, filter.ID)
+	return err
+}
+
+// DeleteNoiseFilter returns sql.ErrNoRows when there's no filter with this id
+func (d *DatabaseInstance) DeleteNoiseFilter(id int) error {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	result, err := d.conn().ExecContext(ctx, "DELETE FROM noisefilters WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
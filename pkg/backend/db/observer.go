@@ -8,6 +8,7 @@ import (
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/workqueue"
@@ -16,7 +17,7 @@ import (
 	"logsviewer/pkg/backend/log"
 )
 
-func NewObjectStore(storeDB *DatabaseInstance) *ObjectStore {
+func NewObjectStore(storeDB Store) *ObjectStore {
 
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "objectStore")
 	c := &ObjectStore{
@@ -30,18 +31,28 @@ func NewObjectStore(storeDB *DatabaseInstance) *ObjectStore {
 
 type ObjectStore struct {
 	Queue      workqueue.RateLimitingInterface
-	storeDB    *DatabaseInstance
+	storeDB    Store
 	lockDBConn *sync.Mutex
 	wg         sync.WaitGroup
+
+	// importID and gatherTime identify the import the objects come from,
+	// once it is stored
+	importID   int
+	gatherTime time.Time
 }
 
 func (c *ObjectStore) Run(threadiness int, stopCh chan struct{}) {
 	defer c.Queue.ShutDown()
 	//workers := 1
 
+	// the workers outlive stopCh until every queued object is stored, an
+	// import closes it as soon as all of its objects are queued
+	workersStopCh := make(chan struct{})
+	defer close(workersStopCh)
+
 	// Start the actual work
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		go wait.Until(c.runWorker, time.Second, workersStopCh)
 	}
 
 	<-stopCh
@@ -56,19 +67,11 @@ func (c *ObjectStore) runWorker() {
 }
 
 func (c *ObjectStore) connectDatabaseIfNeeded() bool {
-	if c.storeDB == nil {
-		c.lockDBConn.Lock()
-		defer c.lockDBConn.Unlock()
-
-		dbInst, err := NewDatabaseInstance()
-		if err != nil {
-			log.Log.Println("failed to connect to database", err)
-			return false
-		}
-		c.storeDB = dbInst
-	}
-	return true
+	c.lockDBConn.Lock()
+	defer c.lockDBConn.Unlock()
 
+	// the store is owned by the caller, which knows which backend to connect to
+	return c.storeDB != nil
 }
 
 func (c *ObjectStore) Execute() bool {
@@ -129,9 +132,94 @@ func (d *ObjectStore) storeImportedMustGather(img *ImportedMustGather) error {
 		log.Log.Println("failed to store obj  ", img, " err: ", err)
 		return err
 	}
+	d.importID = img.ID
+	d.gatherTime = img.GatherTime
+	// the objects that follow are those of the gathered cluster
+	d.storeDB = d.storeDB.InCluster(img.Cluster)
 	return nil
 }
 
+// storeSnapshot records the state of an object in the current import, and
+// tells whether the object itself should be stored as the latest state
+func (d *ObjectStore) storeSnapshot(kind string, uid string, name string, namespace string, content []byte) (bool, error) {
+	if d.importID == 0 || uid == "" {
+		return true, nil
+	}
+	latest, err := d.storeDB.StoreSnapshot(&Snapshot{
+		Kind:       kind,
+		UUID:       uid,
+		ImportID:   d.importID,
+		Name:       name,
+		Namespace:  namespace,
+		GatherTime: d.gatherTime,
+		Content:    content,
+	})
+	if err != nil {
+		log.Log.Println("failed to store snapshot of ", kind, " ", name, " err: ", err)
+		return false, err
+	}
+	if !latest {
+		log.Log.Println("keeping the state of ", kind, " ", name, " from a later must-gather")
+	}
+	return latest, nil
+}
+
+// restore stores an object back as the latest state from the content of
+// one of its snapshots, outside of any import
+func (d *ObjectStore) restore(kind string, content []byte) error {
+	var err error
+	switch kind {
+	case "pods":
+		pod := &k8sv1.Pod{}
+		if err = json.Unmarshal(content, pod); err == nil {
+			err = d.storePod(pod)
+		}
+	case "nodes":
+		node := &k8sv1.Node{}
+		if err = json.Unmarshal(content, node); err == nil {
+			err = d.storeNode(node)
+		}
+	case "vms":
+		vm := &kubevirtv1.VirtualMachine{}
+		if err = json.Unmarshal(content, vm); err == nil {
+			err = d.storeVm(vm)
+		}
+	case "vmis":
+		vmi := &kubevirtv1.VirtualMachineInstance{}
+		if err = json.Unmarshal(content, vmi); err == nil {
+			err = d.storeVmi(vmi)
+		}
+	case "migrations":
+		vmim := kubevirtv1.VirtualMachineInstanceMigration{}
+		if err = json.Unmarshal(content, &vmim); err == nil {
+			err = d.storeVmiMigration(vmim)
+		}
+	case "pvcs":
+		pvc := &k8sv1.PersistentVolumeClaim{}
+		if err = json.Unmarshal(content, pvc); err == nil {
+			err = d.storePVC(pvc)
+		}
+	case "subscriptions":
+		sub := &v1alpha1.Subscription{}
+		if err = json.Unmarshal(content, sub); err == nil {
+			err = d.storeSubscription(sub)
+		}
+	case "csvs":
+		csv := &v1alpha1.ClusterServiceVersion{}
+		if err = json.Unmarshal(content, csv); err == nil {
+			err = d.storeCSV(csv)
+		}
+	case "installplans":
+		plan := &v1alpha1.InstallPlan{}
+		if err = json.Unmarshal(content, plan); err == nil {
+			err = d.storeInstallPlan(plan)
+		}
+	default:
+		err = fmt.Errorf("unknown snapshot kind %q", kind)
+	}
+	return err
+}
+
 func (d *ObjectStore) storePod(pod *k8sv1.Pod) error {
 	jsonBytes, err := json.Marshal(pod)
 	if err != nil {
@@ -160,6 +248,9 @@ func (d *ObjectStore) storePod(pod *k8sv1.Pod) error {
 		Content:          jsonBytes,
 		CreatedBy:        createdByUID,
 	}
+	if latest, err := d.storeSnapshot("pods", uid, name, namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StorePod(storeObj); err != nil {
 		log.Log.Println("failed to store obj  ", storeObj, " err: ", err)
 		return err
@@ -206,6 +297,9 @@ func (d *ObjectStore) storeNode(node *k8sv1.Node) error {
 		ContainerRuntimeVersion: node.Status.NodeInfo.ContainerRuntimeVersion,
 		Content:                 jsonBytes,
 	}
+	if latest, err := d.storeSnapshot("nodes", storeObj.SystemUUID, name, "", jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StoreNode(storeObj); err != nil {
 		log.Log.Println("failed to store obj  ", storeObj, " err: ", err)
 		return err
@@ -233,6 +327,9 @@ func (d *ObjectStore) storeVm(vm *kubevirtv1.VirtualMachine) error {
 		Status:       string(vm.Status.PrintableStatus),
 		Content:      jsonBytes,
 	}
+	if latest, err := d.storeSnapshot("vms", uid, name, namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StoreVm(storeObj); err != nil {
 		log.Log.Println("failed to store vm obj  ", storeObj, " err: ", err)
 		return err
@@ -260,6 +357,9 @@ func (d *ObjectStore) storeVmi(vmi *kubevirtv1.VirtualMachineInstance) error {
 		Status:       vmi.Status,
 		Content:      jsonBytes,
 	}
+	if latest, err := d.storeSnapshot("vmis", uid, name, namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StoreVmi(storeObj); err != nil {
 		log.Log.Println("failed to store vmi obj  ", storeObj, " err: ", err)
 		return err
@@ -296,6 +396,9 @@ func (d *ObjectStore) storeVmiMigration(vmim kubevirtv1.VirtualMachineInstanceMi
 		storeObj.Completed = migrationState.Completed
 		storeObj.Failed = migrationState.Failed
 	}
+	if latest, err := d.storeSnapshot("migrations", uid, name, namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StoreVmiMigration(storeObj); err != nil {
 		log.Log.Println("failed to store vmi migration obj  ", storeObj, " err: ", err)
 		return err
@@ -347,6 +450,9 @@ func (d *ObjectStore) storePVC(pvc *k8sv1.PersistentVolumeClaim) error {
 		CreationTime:     pvc.CreationTimestamp,
 		Content:          jsonBytes,
 	}
+	if latest, err := d.storeSnapshot("pvcs", uid, name, namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StorePVC(storeObj); err != nil {
 		log.Log.Println("failed to store obj  ", storeObj, " err: ", err)
 		return err
@@ -375,6 +481,9 @@ func (d *ObjectStore) storeSubscription(sub *v1alpha1.Subscription) error {
 		CreationTime: sub.CreationTimestamp,
 		Content:      jsonBytes,
 	}
+	if latest, err := d.storeSnapshot("subscriptions", storeObj.UUID, storeObj.Name, storeObj.Namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
 	if err := d.storeDB.StoreSubscription(storeObj); err != nil {
 		log.Log.Println("failed to store subscription obj  ", storeObj, " err: ", err)
 		return err
@@ -382,6 +491,110 @@ func (d *ObjectStore) storeSubscription(sub *v1alpha1.Subscription) error {
 	return nil
 }
 
+func (d *ObjectStore) storeCSV(csv *v1alpha1.ClusterServiceVersion) error {
+	jsonBytes, err := json.Marshal(csv)
+	if err != nil {
+		log.Log.Println("failed to marshal csv object ", csv, " err: ", err)
+	}
+
+	storeObj := &ClusterServiceVersion{
+		UUID: string(csv.UID),
+
+		Name:      csv.Name,
+		Namespace: csv.Namespace,
+		Version:   csv.Spec.Version.String(),
+		Phase:     string(csv.Status.Phase),
+		Replaces:  csv.Spec.Replaces,
+
+		CreationTime: csv.CreationTimestamp,
+		Content:      jsonBytes,
+	}
+	if csv.Status.LastTransitionTime != nil {
+		storeObj.LastTransitionTime = *csv.Status.LastTransitionTime
+	}
+	if latest, err := d.storeSnapshot("csvs", storeObj.UUID, storeObj.Name, storeObj.Namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
+	if err := d.storeDB.StoreCSV(storeObj); err != nil {
+		log.Log.Println("failed to store csv obj  ", storeObj, " err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (d *ObjectStore) storeInstallPlan(plan *v1alpha1.InstallPlan) error {
+	jsonBytes, err := json.Marshal(plan)
+	if err != nil {
+		log.Log.Println("failed to marshal install plan object ", plan, " err: ", err)
+	}
+
+	storeObj := &InstallPlan{
+		UUID: string(plan.UID),
+
+		Name:      plan.Name,
+		Namespace: plan.Namespace,
+		Phase:     string(plan.Status.Phase),
+		CSVNames:  strings.Join(plan.Spec.ClusterServiceVersionNames, ","),
+		Approved:  plan.Spec.Approved,
+
+		CreationTime: plan.CreationTimestamp,
+		Content:      jsonBytes,
+	}
+	for _, condition := range plan.Status.Conditions {
+		if condition.LastTransitionTime != nil && condition.LastTransitionTime.After(storeObj.LastTransitionTime.Time) {
+			storeObj.LastTransitionTime = *condition.LastTransitionTime
+		}
+	}
+	if latest, err := d.storeSnapshot("installplans", storeObj.UUID, storeObj.Name, storeObj.Namespace, jsonBytes); err != nil || !latest {
+		return err
+	}
+	if err := d.storeDB.StoreInstallPlan(storeObj); err != nil {
+		log.Log.Println("failed to store install plan obj  ", storeObj, " err: ", err)
+		return err
+	}
+	return nil
+}
+
+func (d *ObjectStore) storeEvent(event *k8sv1.Event) error {
+	jsonBytes, err := json.Marshal(event)
+	if err != nil {
+		log.Log.Println("failed to marshal event object ", event, " err: ", err)
+	}
+
+	storeObj := &Event{
+		UUID: string(event.UID),
+
+		Name:         event.Name,
+		Namespace:    event.Namespace,
+		InvolvedKind: event.InvolvedObject.Kind,
+		InvolvedName: event.InvolvedObject.Name,
+		InvolvedUID:  string(event.InvolvedObject.UID),
+		Type:         event.Type,
+		Reason:       event.Reason,
+		Message:      event.Message,
+		Count:        int(event.Count),
+
+		FirstTimestamp: event.FirstTimestamp,
+		LastTimestamp:  event.LastTimestamp,
+		Content:        jsonBytes,
+	}
+	// events.k8s.io events only carry the event time
+	if storeObj.FirstTimestamp.IsZero() {
+		storeObj.FirstTimestamp = metav1.Time{Time: event.EventTime.Time}
+	}
+	if storeObj.LastTimestamp.IsZero() {
+		storeObj.LastTimestamp = storeObj.FirstTimestamp
+	}
+	if storeObj.Count == 0 {
+		storeObj.Count = 1
+	}
+	if err := d.storeDB.StoreEvent(storeObj); err != nil {
+		log.Log.Println("failed to store event obj  ", storeObj, " err: ", err)
+		return err
+	}
+	return nil
+}
+
 func (d *ObjectStore) processObject(obj interface{}) {
 
 	switch obj.(type) {
@@ -432,6 +645,21 @@ func (d *ObjectStore) processObject(obj interface{}) {
 		if err := d.storeSubscription(sub); err == nil {
 			log.Log.Println("stored subscription obj  ", sub)
 		}
+	case *k8sv1.Event:
+		event := obj.(*k8sv1.Event)
+		if err := d.storeEvent(event); err == nil {
+			log.Log.Println("stored event obj  ", event.Name)
+		}
+	case *v1alpha1.ClusterServiceVersion:
+		csv := obj.(*v1alpha1.ClusterServiceVersion)
+		if err := d.storeCSV(csv); err == nil {
+			log.Log.Println("stored csv obj  ", csv.Name)
+		}
+	case *v1alpha1.InstallPlan:
+		plan := obj.(*v1alpha1.InstallPlan)
+		if err := d.storeInstallPlan(plan); err == nil {
+			log.Log.Println("stored install plan obj  ", plan.Name)
+		}
 	default:
 		jsonBytes, err := json.Marshal(obj)
 		if err != nil {
@@ -476,6 +704,18 @@ func (d *ObjectStore) Add(obj interface{}) {
 		sub := obj.(*v1alpha1.Subscription)
 		d.wg.Add(1)
 		d.Queue.Add(sub)
+	case *k8sv1.Event:
+		event := obj.(*k8sv1.Event)
+		d.wg.Add(1)
+		d.Queue.Add(event)
+	case *v1alpha1.ClusterServiceVersion:
+		csv := obj.(*v1alpha1.ClusterServiceVersion)
+		d.wg.Add(1)
+		d.Queue.Add(csv)
+	case *v1alpha1.InstallPlan:
+		plan := obj.(*v1alpha1.InstallPlan)
+		d.wg.Add(1)
+		d.Queue.Add(plan)
 	default:
 		log.Log.Println("Cannot store unsupported obj ", v)
 	}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"logsviewer/pkg/backend/log"
+)
+
+// operatorPodPrefixes name the pods of the operators taking part in a CNV
+// install or upgrade: HCO, virt-operator, cdi-operator and OLM itself
+var operatorPodPrefixes = []string{
+	"hco-operator",
+	"hyperconverged-cluster-operator",
+	"hco-webhook",
+	"virt-operator",
+	"cdi-operator",
+	"olm-operator",
+	"catalog-operator",
+}
+
+// GetSubscriptionQueryParams collects the CSVs and InstallPlans of a
+// subscription, and the operator pods acting on them.
+func (d *DatabaseInstance) GetSubscriptionQueryParams(subscriptionUUID string) (QueryResults, error) {
+	results := QueryResults{}
+
+	var source, sourceNamespace, startingCSV, currentCSV, installedCSV sql.NullString
+	var creationTime time.Time
+	rows := d.queryRow(d.scoped(newSelectQuery("select namespace, source, sourceNamespace, startingCSV, currentCSV, installedCSV, creationTime from subscriptions")).
+		where("uuid = ?", subscriptionUUID))
+	if err := rows.Scan(&results.Namespace, &source, &sourceNamespace, &startingCSV, &currentCSV, &installedCSV, &creationTime); err != nil {
+		if err == sql.ErrNoRows {
+			log.Log.Println("getSubscriptionQueryParams can't find anything with this uuid: ", subscriptionUUID)
+		} else {
+			log.Log.Println("getSubscriptionQueryParams ERROR: ", err, " for uuid: ", subscriptionUUID)
+		}
+		return results, err
+	}
+
+	csvNames := []string{}
+	for _, name := range []string{startingCSV.String, installedCSV.String, currentCSV.String} {
+		if name != "" && !contains(csvNames, name) {
+			csvNames = append(csvNames, name)
+		}
+	}
+	if err := d.collectOperatorQueryParams(&results, csvNames, source.String, sourceNamespace.String); err != nil {
+		return results, err
+	}
+	if results.StartTimestamp.IsZero() {
+		results.StartTimestamp = creationTime
+	}
+	return results, nil
+}
+
+// GetCSVQueryParams collects the InstallPlans of a CSV, the CSV it replaces,
+// and the operator pods acting on them.
+func (d *DatabaseInstance) GetCSVQueryParams(csvUUID string) (QueryResults, error) {
+	results := QueryResults{}
+
+	var name string
+	var creationTime time.Time
+	rows := d.queryRow(d.scoped(newSelectQuery("select name, namespace, creationTime from csvs")).where("uuid = ?", csvUUID))
+	if err := rows.Scan(&name, &results.Namespace, &creationTime); err != nil {
+		if err == sql.ErrNoRows {
+			log.Log.Println("getCSVQueryParams can't find anything with this uuid: ", csvUUID)
+		} else {
+			log.Log.Println("getCSVQueryParams ERROR: ", err, " for uuid: ", csvUUID)
+		}
+		return results, err
+	}
+
+	// the subscription installing the CSV tells which catalog it came from
+	var source, sourceNamespace sql.NullString
+	err := d.queryRow(d.scoped(newSelectQuery("select source, sourceNamespace from subscriptions")).
+		where("namespace = ?", results.Namespace).
+		where("currentCSV = ? or installedCSV = ?", name, name)).Scan(&source, &sourceNamespace)
+	if err != nil && err != sql.ErrNoRows {
+		return results, err
+	}
+
+	if err := d.collectOperatorQueryParams(&results, []string{name}, source.String, sourceNamespace.String); err != nil {
+		return results, err
+	}
+	if results.StartTimestamp.IsZero() {
+		results.StartTimestamp = creationTime
+	}
+	return results, nil
+}
+
+// collectOperatorQueryParams adds the CSVs replaced by csvNames and the
+// InstallPlans installing any of them, bounds the query from the first
+// creation to the last transition, and collects the operator pods and the
+// pods of the catalog source
+func (d *DatabaseInstance) collectOperatorQueryParams(results *QueryResults, csvNames []string, source string, sourceNamespace string) error {
+	type transition struct {
+		// related is the replaced CSV of a CSV, the comma separated CSVs of an InstallPlan
+		name, related  string
+		creation, last time.Time
+	}
+	scan := func(query *selectQuery) ([]transition, error) {
+		ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+		defer cancel()
+		rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		transitions := []transition{}
+		for rows.Next() {
+			t := transition{}
+			var related sql.NullString
+			var last sql.NullTime
+			if err := rows.Scan(&t.name, &related, &t.creation, &last); err != nil {
+				return nil, err
+			}
+			t.related = related.String
+			t.last = last.Time
+			transitions = append(transitions, t)
+		}
+		return transitions, rows.Err()
+	}
+
+	csvs, err := scan(d.scoped(newSelectQuery("select name, replaces, creationTime, lastTransitionTime from csvs")).
+		where("namespace = ?", results.Namespace).
+		order("creationTime ASC"))
+	if err != nil {
+		return err
+	}
+	results.CSVNames = append([]string{}, csvNames...)
+	for _, csv := range csvs {
+		if !contains(csvNames, csv.name) {
+			continue
+		}
+		if csv.related != "" && !contains(results.CSVNames, csv.related) {
+			results.CSVNames = append(results.CSVNames, csv.related)
+		}
+		extendWindow(results, csv.creation, csv.last)
+	}
+	// the replaced CSVs only bound the end, they were created long before the upgrade
+	for _, csv := range csvs {
+		if contains(results.CSVNames, csv.name) && !contains(csvNames, csv.name) {
+			extendWindow(results, time.Time{}, csv.last)
+		}
+	}
+
+	plans, err := scan(d.scoped(newSelectQuery("select name, csvNames, creationTime, lastTransitionTime from installplans")).
+		where("namespace = ?", results.Namespace).
+		order("creationTime ASC"))
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		for _, name := range strings.Split(plan.related, ",") {
+			if contains(csvNames, name) {
+				results.InstallPlanNames = append(results.InstallPlanNames, plan.name)
+				extendWindow(results, plan.creation, plan.last)
+				break
+			}
+		}
+	}
+
+	podsQuery := d.scoped(newSelectQuery("select uuid, name, nodeName, pvcs from pods"))
+	conditions := []string{}
+	args := []interface{}{}
+	for _, prefix := range operatorPodPrefixes {
+		conditions = append(conditions, "name like ?")
+		args = append(args, prefix+"%")
+	}
+	if source != "" && sourceNamespace != "" {
+		conditions = append(conditions, "(namespace = ? and name like ?)")
+		args = append(args, sourceNamespace, source+"%")
+	}
+	podsQuery.where(strings.Join(conditions, " or "), args...).order("creationTime ASC")
+	results.InvolvedOperatorPods, err = d.queryPods(podsQuery)
+	return err
+}
+
+// extendWindow widens the time range of results to cover start and end,
+// zero times are ignored
+func extendWindow(results *QueryResults, start time.Time, end time.Time) {
+	if !start.IsZero() && (results.StartTimestamp.IsZero() || start.Before(results.StartTimestamp)) {
+		results.StartTimestamp = start
+	}
+	if !end.IsZero() && end.After(results.EndTimestamp) {
+		results.EndTimestamp = end
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"strings"
+)
+
+// selectQuery assembles a SELECT statement from a fixed column list and a set
+// of conditions. Values are never formatted into the statement, they are
+// collected separately and passed to the driver as bound parameters.
+type selectQuery struct {
+	base       string
+	conditions []string
+	args       []interface{}
+	orderBy    string
+}
+
+func newSelectQuery(base string) *selectQuery {
+	return &selectQuery{base: base}
+}
+
+// where adds a condition using '?' placeholders for each of the given args.
+// Conditions are AND-ed together, each one wrapped in parentheses so that
+// an OR inside a condition doesn't leak into its neighbours.
+func (q *selectQuery) where(condition string, args ...interface{}) *selectQuery {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// whereIn adds a "column IN (...)" condition with one placeholder per value.
+// An empty list matches nothing.
+func (q *selectQuery) whereIn(column string, values []string) *selectQuery {
+	if len(values) == 0 {
+		return q.where("1 = 0")
+	}
+	return q.where(column+" IN ("+placeholders(len(values))+")", toArgs(values)...)
+}
+
+// whereNotIn adds a "column NOT IN (...)" condition with one placeholder per value.
+func (q *selectQuery) whereNotIn(column string, values []string) *selectQuery {
+	if len(values) == 0 {
+		return q
+	}
+	return q.where(column+" NOT IN ("+placeholders(len(values))+")", toArgs(values)...)
+}
+
+func (q *selectQuery) order(orderBy string) *selectQuery {
+	q.orderBy = orderBy
+	return q
+}
+
+func (q *selectQuery) String() string {
+	var sb strings.Builder
+	sb.WriteString(q.base)
+	if len(q.conditions) > 0 {
+		sb.WriteString(" where (")
+		sb.WriteString(strings.Join(q.conditions, ") AND ("))
+		sb.WriteString(")")
+	}
+	if q.orderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(q.orderBy)
+	}
+	return sb.String()
+}
+
+func (q *selectQuery) Args() []interface{} {
+	return q.args
+}
+
+// paginated returns the statement and its args limited to the requested page.
+// A perPage of -1 returns every row.
+func (q *selectQuery) paginated(page int, perPage int) (string, []interface{}) {
+	if perPage == -1 {
+		return q.String(), q.args
+	}
+	return q.limited(perPage, (page-1)*perPage)
+}
+
+// limited returns the statement and its args limited to limit rows after offset
+func (q *selectQuery) limited(limit int, offset int) (string, []interface{}) {
+	args := append(append([]interface{}{}, q.args...), limit, offset)
+	return q.String() + " LIMIT ? OFFSET ?", args
+}
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// toArgs converts values to bound parameters
+func toArgs(values []string) []interface{} {
+	args := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		args = append(args, value)
+	}
+	return args
+}
+
+// likeEscape escapes the LIKE wildcards of value, the condition must
+// declare ESCAPE '!'
+func likeEscape(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     *selectQuery
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:      "no condition",
+			query:     newSelectQuery("select name from pods"),
+			statement: "select name from pods",
+		},
+		{
+			name:      "conditions are wrapped",
+			query:     newSelectQuery("select name from pods").where("name = ? or name = ?", "a", "b").where("namespace = ?", "ns"),
+			statement: "select name from pods where (name = ? or name = ?) AND (namespace = ?)",
+			args:      []interface{}{"a", "b", "ns"},
+		},
+		{
+			name:      "values are bound, not formatted",
+			query:     newSelectQuery("select name from pods").where("name = ?", `it's "quoted" \ 100% _`),
+			statement: "select name from pods where (name = ?)",
+			args:      []interface{}{`it's "quoted" \ 100% _`},
+		},
+		{
+			name:      "empty whereIn matches nothing",
+			query:     newSelectQuery("select name from pods").whereIn("phase", nil),
+			statement: "select name from pods where (1 = 0)",
+		},
+		{
+			name:      "whereIn",
+			query:     newSelectQuery("select name from pods").whereIn("phase", []string{"Running", "it's"}),
+			statement: "select name from pods where (phase IN (?, ?))",
+			args:      []interface{}{"Running", "it's"},
+		},
+		{
+			name:      "empty whereNotIn matches everything",
+			query:     newSelectQuery("select name from pods").whereNotIn("phase", []string{}),
+			statement: "select name from pods",
+		},
+		{
+			name:      "whereNotIn",
+			query:     newSelectQuery("select name from pods").whereNotIn("phase", []string{"Failed"}).order("name ASC"),
+			statement: "select name from pods where (phase NOT IN (?)) ORDER BY name ASC",
+			args:      []interface{}{"Failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if statement := tt.query.String(); statement != tt.statement {
+				t.Errorf("statement = %q, want %q", statement, tt.statement)
+			}
+			if args := tt.query.Args(); len(args) != len(tt.args) || (len(args) > 0 && !reflect.DeepEqual(args, tt.args)) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestPaginated(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      int
+		perPage   int
+		statement string
+		args      []interface{}
+	}{
+		{name: "every row", page: 1, perPage: -1, statement: "select name from pods where (namespace = ?)", args: []interface{}{"ns"}},
+		{name: "first page", page: 1, perPage: 10, statement: "select name from pods where (namespace = ?) LIMIT ? OFFSET ?", args: []interface{}{"ns", 10, 0}},
+		{name: "third page", page: 3, perPage: 25, statement: "select name from pods where (namespace = ?) LIMIT ? OFFSET ?", args: []interface{}{"ns", 25, 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := newSelectQuery("select name from pods").where("namespace = ?", "ns")
+			statement, args := query.paginated(tt.page, tt.perPage)
+			if statement != tt.statement {
+				t.Errorf("statement = %q, want %q", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+			if len(query.Args()) != 1 {
+				t.Errorf("paginated changed the args of the query: %v", query.Args())
+			}
+		})
+	}
+}
+
+func TestLikeEscape(t *testing.T) {
+	for value, want := range map[string]string{
+		"plain":     "plain",
+		"100%":      "100!%",
+		"under_":    "under!_",
+		"bang!":     "bang!!",
+		`it's "\"`:  `it's "\"`,
+		"!%_ mixed": "!!!%!_ mixed",
+	} {
+		if escaped := likeEscape(value); escaped != want {
+			t.Errorf("likeEscape(%q) = %q, want %q", value, escaped, want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// resolveSpec tells how to read the objects of a kind, a node is identified
+// by its system uuid like everywhere else in the API
+type resolveSpec struct {
+	kind      string
+	table     string
+	uid       string
+	namespace string
+	node      string
+}
+
+var (
+	resolvePods       = resolveSpec{kind: "pods", table: "pods", uid: "uuid", namespace: "namespace", node: "nodeName"}
+	resolveVMIs       = resolveSpec{kind: "vmis", table: "vmis", uid: "uuid", namespace: "namespace", node: "nodeName"}
+	resolveNodes      = resolveSpec{kind: "nodes", table: "nodes", uid: "systemUuid", namespace: "''", node: "name"}
+	resolvePVCs       = resolveSpec{kind: "pvcs", table: "pvcs", uid: "uuid", namespace: "namespace", node: "''"}
+	resolveMigrations = resolveSpec{kind: "migrations", table: "vmimigrations", uid: "uuid", namespace: "namespace", node: "sourceNode"}
+)
+
+// Resolve maps a UID or an IP address found in a log line back to the
+// objects it identifies. UIDs are looked up among pods, VMIs, nodes, PVCs
+// and migrations, IPs among the pod IPs, the VMI interface IPs and the node
+// addresses.
+func (d *DatabaseInstance) Resolve(value string) ([]ResolvedObject, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		return d.resolveIP(ip.String())
+	}
+	return d.resolveUID(value)
+}
+
+func (d *DatabaseInstance) resolveUID(uid string) ([]ResolvedObject, error) {
+	resolved := []ResolvedObject{}
+	for _, spec := range []resolveSpec{resolvePods, resolveVMIs, resolvePVCs, resolveMigrations} {
+		found, err := d.resolveCandidates(spec, spec.uid+" = ?", uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range found {
+			object.MatchedBy = "uid"
+			resolved = append(resolved, object)
+		}
+	}
+
+	// the metadata uid of a node is only kept in its content
+	nodes, err := d.resolveCandidates(resolveNodes, "systemUuid = ? or CAST(content AS CHAR) LIKE ? ESCAPE '!'", uid, `%"`+likeEscape(uid)+`"%`)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		switch {
+		case node.UID == uid:
+			node.MatchedBy = "systemUuid"
+		case node.metadataUID == uid:
+			node.MatchedBy = "uid"
+		default:
+			continue
+		}
+		resolved = append(resolved, node)
+	}
+	return resolved, nil
+}
+
+func (d *DatabaseInstance) resolveIP(ip string) ([]ResolvedObject, error) {
+	pattern := `%"` + likeEscape(ip) + `"%`
+	resolved := []ResolvedObject{}
+
+	pods, err := d.resolveCandidates(resolvePods, "CAST(content AS CHAR) LIKE ? ESCAPE '!'", pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range pods {
+		pod := k8sv1.Pod{}
+		if err := json.Unmarshal(object.content, &pod); err != nil {
+			continue
+		}
+		ips := []string{pod.Status.PodIP}
+		for _, podIP := range pod.Status.PodIPs {
+			ips = append(ips, podIP.IP)
+		}
+		if contains(ips, ip) {
+			object.MatchedBy = "podIP"
+			resolved = append(resolved, object)
+		}
+	}
+
+	vmis, err := d.resolveCandidates(resolveVMIs, "CAST(content AS CHAR) LIKE ? ESCAPE '!'", pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range vmis {
+		vmi := kubevirtv1.VirtualMachineInstance{}
+		if err := json.Unmarshal(object.content, &vmi); err != nil {
+			continue
+		}
+		for _, iface := range vmi.Status.Interfaces {
+			if iface.IP == ip || contains(iface.IPs, ip) {
+				object.MatchedBy = "interfaceIP"
+				resolved = append(resolved, object)
+				break
+			}
+		}
+	}
+
+	nodes, err := d.resolveCandidates(resolveNodes, "internalIP = ? or CAST(content AS CHAR) LIKE ? ESCAPE '!'", ip, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range nodes {
+		node := k8sv1.Node{}
+		if err := json.Unmarshal(object.content, &node); err != nil {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Address == ip {
+				object.MatchedBy = "nodeAddress"
+				resolved = append(resolved, object)
+				break
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// resolveCandidates reads the objects of a kind matching condition, with
+// the owners from their content
+func (d *DatabaseInstance) resolveCandidates(spec resolveSpec, condition string, args ...interface{}) ([]ResolvedObject, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	query := d.scoped(newSelectQuery("select "+spec.uid+", name, "+spec.namespace+", "+spec.node+", content from "+spec.table)).
+		where(condition, args...)
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := []ResolvedObject{}
+	for rows.Next() {
+		object := ResolvedObject{Kind: spec.kind, Owners: []Owner{}}
+		var uid, namespace, node sql.NullString
+		if err := rows.Scan(&uid, &object.Name, &namespace, &node, &object.content); err != nil {
+			return nil, err
+		}
+		object.UID = uid.String
+		object.Namespace = namespace.String
+		object.Node = node.String
+
+		meta := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(object.content, &meta); err == nil {
+			object.metadataUID = string(meta.UID)
+			for _, owner := range meta.OwnerReferences {
+				object.Owners = append(object.Owners, Owner{
+					Kind:       owner.Kind,
+					Name:       owner.Name,
+					UID:        string(owner.UID),
+					Controller: owner.Controller != nil && *owner.Controller,
+				})
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
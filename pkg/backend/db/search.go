@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// searchCandidatesPerKind caps the rows read from each table before ranking
+const searchCandidatesPerKind = 200
+
+const (
+	scoreUID          = 100
+	scoreName         = 90
+	scoreAddress      = 80
+	scoreNode         = 70
+	scoreOtherField   = 60
+	scoreUIDPrefix    = 50
+	scoreNamePrefix   = 40
+	scoreNameContains = 20
+)
+
+// searchSpec tells which columns of a table are matched, a missing column
+// is the empty string literal
+type searchSpec struct {
+	kind      string
+	table     string
+	uid       string
+	name      string
+	namespace string
+	node      string
+	// others are matched exactly, such as the VMI of a migration
+	others []string
+	// addresses tells the content holds IP or MAC addresses
+	addresses bool
+}
+
+var searchSpecs = []searchSpec{
+	{kind: "pods", table: "pods", uid: "uuid", name: "name", namespace: "namespace", node: "nodeName", addresses: true},
+	{kind: "vmis", table: "vmis", uid: "uuid", name: "name", namespace: "namespace", node: "nodeName", addresses: true},
+	{kind: "vms", table: "vms", uid: "uuid", name: "name", namespace: "namespace", node: "''"},
+	{kind: "nodes", table: "nodes", uid: "systemUuid", name: "name", namespace: "''", node: "name", others: []string{"internalIP", "hostName"}, addresses: true},
+	{kind: "pvcs", table: "pvcs", uid: "uuid", name: "name", namespace: "namespace", node: "''", others: []string{"volumeName"}},
+	{kind: "migrations", table: "vmimigrations", uid: "uuid", name: "name", namespace: "namespace", node: "sourceNode", others: []string{"vmiName", "targetNode"}},
+	{kind: "subscriptions", table: "subscriptions", uid: "uuid", name: "name", namespace: "namespace", node: "''"},
+	{kind: "csvs", table: "csvs", uid: "uuid", name: "name", namespace: "namespace", node: "''"},
+	{kind: "installplans", table: "installplans", uid: "uuid", name: "name", namespace: "namespace", node: "''"},
+}
+
+// SearchKinds lists the kinds Search looks into
+func SearchKinds() []string {
+	kinds := make([]string, 0, len(searchSpecs))
+	for _, spec := range searchSpecs {
+		kinds = append(kinds, spec.kind)
+	}
+	return kinds
+}
+
+// Search looks for term in the names, UIDs and nodes of every kind, and in
+// the IP and MAC addresses of pods, VMIs and nodes. Results are ranked by
+// how well they match, exact UIDs first and partial names last. An empty
+// kinds searches every kind.
+func (d *DatabaseInstance) Search(term string, kinds []string, limit int) ([]SearchResult, error) {
+	term = strings.TrimSpace(term)
+	results := []SearchResult{}
+	if term == "" {
+		return results, nil
+	}
+
+	// addresses are looked up as JSON string values of the content
+	address := ""
+	if ip := net.ParseIP(term); ip != nil {
+		address = ip.String()
+	} else if mac, err := net.ParseMAC(term); err == nil {
+		address = mac.String()
+	}
+
+	for _, spec := range searchSpecs {
+		if len(kinds) > 0 && !contains(kinds, spec.kind) {
+			continue
+		}
+		found, err := d.searchKind(spec, term, address)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		return results[i].Name < results[j].Name
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (d *DatabaseInstance) searchKind(spec searchSpec, term string, address string) ([]SearchResult, error) {
+	columns := append([]string{spec.uid, spec.name, spec.namespace, spec.node}, spec.others...)
+	conditions := []string{
+		spec.uid + " = ?",
+		spec.uid + " LIKE ? ESCAPE '!'",
+		spec.name + " LIKE ? ESCAPE '!'",
+	}
+	args := []interface{}{term, likeEscape(term) + "%", "%" + likeEscape(term) + "%"}
+	if spec.node != "''" {
+		conditions = append(conditions, spec.node+" = ?")
+		args = append(args, term)
+	}
+	for _, other := range spec.others {
+		conditions = append(conditions, other+" = ?")
+		args = append(args, term)
+	}
+	if spec.addresses && address != "" {
+		// SQLite doesn't match patterns against the blobs the content is stored as
+		conditions = append(conditions, "CAST(content AS CHAR) LIKE ? ESCAPE '!'")
+		args = append(args, `%"`+likeEscape(address)+`"%`)
+	}
+
+	query := d.scoped(newSelectQuery("select "+strings.Join(columns, ", ")+" from "+spec.table)).
+		where(strings.Join(conditions, " or "), args...).
+		order(spec.name + " ASC")
+	statement, statementArgs := query.paginated(1, searchCandidatesPerKind)
+
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+	rows, err := d.conn().QueryContext(ctx, statement, statementArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		result := SearchResult{
+			Kind:      spec.kind,
+			UID:       values[0].String,
+			Name:      values[1].String,
+			Namespace: values[2].String,
+			Node:      values[3].String,
+		}
+		result.Field, result.Score = rank(term, result, spec.others, values[4:])
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// rank tells which field matched term best and how well, a row matching
+// none of its columns matched on an address of its content
+func rank(term string, result SearchResult, others []string, otherValues []sql.NullString) (string, int) {
+	switch {
+	case result.UID == term:
+		return "uid", scoreUID
+	case strings.EqualFold(result.Name, term):
+		return "name", scoreName
+	case result.Node == term:
+		return "node", scoreNode
+	}
+	for i, value := range otherValues {
+		if value.String == term {
+			return others[i], scoreOtherField
+		}
+	}
+	lowerName, lowerTerm := strings.ToLower(result.Name), strings.ToLower(term)
+	switch {
+	case strings.HasPrefix(result.UID, term):
+		return "uid", scoreUIDPrefix
+	case strings.HasPrefix(lowerName, lowerTerm):
+		return "name", scoreNamePrefix
+	case strings.Contains(lowerName, lowerTerm):
+		return "name", scoreNameContains
+	}
+	return "address", scoreAddress
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"logsviewer/pkg/backend/log"
+)
+
+var insertSnapshotQuery = upsertQuery{
+	table:   "snapshots",
+	columns: []string{"kind", "uuid", "importId", "name", "namespace", "gatherTime", "content"},
+	key:     "kind, uuid, importId",
+	update:  []string{"name", "namespace", "gatherTime", "content"},
+}
+
+// SnapshotKinds lists the kinds whose snapshots are kept, a node is
+// identified by its system uuid
+func SnapshotKinds() []string {
+	return []string{"pods", "vms", "vmis", "nodes", "pvcs", "migrations", "subscriptions", "csvs", "installplans"}
+}
+
+// StoreSnapshot records the state of an object in an import. The latest
+// state of an object is the one of the import gathered last, an import of
+// an older must-gather only adds to the history of the object.
+func (d *DatabaseInstance) StoreSnapshot(snapshot *Snapshot) (bool, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 1*time.Second)
+	defer cancel()
+
+	stmt, err := d.prepare(ctx, d.dialect.upsert(insertSnapshotQuery))
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx,
+		snapshot.Kind,
+		snapshot.UUID,
+		snapshot.ImportID,
+		snapshot.Name,
+		snapshot.Namespace,
+		snapshot.GatherTime.Format("2006-01-02 15:04:05.999999"),
+		snapshot.Content,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var latest int
+	err = d.queryRow(newSelectQuery("select importId from snapshots").
+		where("kind = ?", snapshot.Kind).
+		where("uuid = ?", snapshot.UUID).
+		order("gatherTime DESC, importId DESC")).Scan(&latest)
+	if err != nil {
+		return false, err
+	}
+	return latest == snapshot.ImportID, nil
+}
+
+// GetObjectHistory lists the snapshots of an object in every import it was
+// found in, by gather time
+func (d *DatabaseInstance) GetObjectHistory(kind string, uid string) ([]Snapshot, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	query := newSelectQuery("select s.kind, s.uuid, s.importId, i.name, s.name, s.namespace, s.gatherTime, s.content "+
+		"from snapshots s left join importedmustgathers i on i.id = s.importId").
+		where("s.kind = ?", kind).
+		where("s.uuid = ?", uid).
+		order("s.gatherTime ASC, s.importId ASC")
+	if d.cluster != "" {
+		query.where("i.cluster = ?", d.cluster)
+	}
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []Snapshot{}
+	for rows.Next() {
+		snapshot := Snapshot{}
+		var importName, namespace sql.NullString
+		var content []byte
+		if err := rows.Scan(&snapshot.Kind, &snapshot.UUID, &snapshot.ImportID, &importName, &snapshot.Name, &namespace, &snapshot.GatherTime, &content); err != nil {
+			return nil, err
+		}
+		snapshot.ImportName = importName.String
+		snapshot.Namespace = namespace.String
+		snapshot.Content = json.RawMessage(content)
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		log.Log.Println("getObjectHistory can't find any snapshots of ", kind, " with this uuid: ", uid)
+		return nil, sql.ErrNoRows
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testCluster = "cluster-a"
+
+// importGather stores the objects the way an import of a must-gather
+// gathered at gatherTime does, and returns the import
+func importGather(t *testing.T, store *DatabaseInstance, name string, gatherTime time.Time, objects ...interface{}) *ImportedMustGather {
+	t.Helper()
+	img := &ImportedMustGather{Name: name, ImportTime: time.Now().UTC(), GatherTime: gatherTime, Cluster: testCluster}
+	objectStore := NewObjectStore(store)
+	if err := objectStore.storeImportedMustGather(img); err != nil {
+		t.Fatalf("failed to store the import %s: %v", name, err)
+	}
+	for _, object := range objects {
+		objectStore.processObject(object)
+	}
+	return img
+}
+
+// testPod is the virt-launcher of a VMI in a phase on a node
+func testPod(uid string, phase k8sv1.PodPhase, node string) *k8sv1.Pod {
+	return &k8sv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "virt-launcher-" + uid, Namespace: "default", UID: types.UID(uid),
+			CreationTimestamp: metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))},
+		Spec:   k8sv1.PodSpec{NodeName: node},
+		Status: k8sv1.PodStatus{Phase: phase},
+	}
+}
+
+func TestImportsInAnyOrderKeepTheLatestState(t *testing.T) {
+	older := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+
+	for _, tt := range []struct {
+		name string
+		// the imports, in the order they're imported
+		gathers []time.Time
+	}{
+		{name: "older first", gathers: []time.Time{older, newer}},
+		{name: "newer first", gathers: []time.Time{newer, older}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			states := map[time.Time]*k8sv1.Pod{
+				older: testPod("pod-uid", k8sv1.PodPending, "node-a"),
+				newer: testPod("pod-uid", k8sv1.PodRunning, "node-b"),
+			}
+			names := map[time.Time]string{older: "older.tar.gz", newer: "newer.tar.gz"}
+			for _, gatherTime := range tt.gathers {
+				importGather(t, store, names[gatherTime], gatherTime, states[gatherTime])
+			}
+
+			// the list row holds the state of the newer gather
+			result, err := store.GetPods(1, -1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows := result["data"].([]map[string]interface{})
+			if len(rows) != 1 || rows[0]["phase"] != string(k8sv1.PodRunning) {
+				t.Errorf("listed %v, want the single pod in phase Running", rows)
+			}
+			pod, err := store.GetPodObject("pod-uid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pod.Status.Phase != k8sv1.PodRunning || pod.Spec.NodeName != "node-b" {
+				t.Errorf("pod is %s on %s, want Running on node-b", pod.Status.Phase, pod.Spec.NodeName)
+			}
+
+			// the history is ordered by gather time whatever the import order
+			history, err := store.GetObjectHistory("pods", "pod-uid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("got %d snapshots, want 2", len(history))
+			}
+			for i, gatherTime := range []time.Time{older, newer} {
+				if !history[i].GatherTime.Equal(gatherTime) || history[i].ImportName != names[gatherTime] {
+					t.Errorf("snapshot %d is %s gathered at %v, want %s gathered at %v",
+						i, history[i].ImportName, history[i].GatherTime, names[gatherTime], gatherTime)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreSnapshotInTransaction(t *testing.T) {
+	store := newTestStore(t)
+	img := importGather(t, store, "older.tar.gz", time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the latest snapshot is read back within the transaction, which holds
+	// the single connection of the test store
+	latest, err := store.inTx(tx).StoreSnapshot(&Snapshot{Kind: "pods", UUID: "pod-uid", ImportID: img.ID, Name: "virt-launcher-pod-uid",
+		Namespace: "default", GatherTime: img.GatherTime, Content: testContent(t, testPod("pod-uid", k8sv1.PodRunning, "node-a"))})
+	if err != nil || !latest {
+		t.Errorf("StoreSnapshot() = %v, %v, want the latest snapshot", latest, err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if history, err := store.GetObjectHistory("pods", "pod-uid"); !IsNotFound(err) {
+		t.Errorf("the rolled back snapshot is stored: %v, %v", history, err)
+	}
+}
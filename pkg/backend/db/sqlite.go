@@ -0,0 +1,53 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDialect stores everything in a single local file using an embedded,
+// pure Go SQLite engine. No database server is needed.
+type sqliteDialect struct {
+	path string
+}
+
+// NewSQLiteDatabaseInstance opens (and creates if needed) the SQLite database at path.
+// Use ":memory:" for a throwaway in-memory database.
+func NewSQLiteDatabaseInstance(path string) (*DatabaseInstance, error) {
+	return newDatabaseInstance(&sqliteDialect{path: path})
+}
+
+func (s *sqliteDialect) driverName() string {
+	return SQLiteDriver
+}
+
+func (s *sqliteDialect) dataSourceName() string {
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", s.path)
+}
+
+func (s *sqliteDialect) configure(db *sql.DB) {
+	// SQLite allows a single writer, and every connection to ":memory:"
+	// would otherwise get its own empty database.
+	db.SetMaxOpenConns(1)
+}
+
+func (s *sqliteDialect) upsert(q upsertQuery) string {
+	if q.key == "" {
+		return q.insertPrefix() + ";"
+	}
+	if len(q.update) == 0 {
+		return q.insertPrefix() + " ON CONFLICT(" + q.key + ") DO NOTHING;"
+	}
+	updates := []string{}
+	for _, column := range q.update {
+		updates = append(updates, fmt.Sprintf("%s=excluded.%s", column, column))
+	}
+	return q.insertPrefix() + " ON CONFLICT(" + q.key + ") DO UPDATE SET " + strings.Join(updates, ", ") + ";"
+}
+
+func (s *sqliteDialect) autoIncrementID() string {
+	return "id INTEGER PRIMARY KEY AUTOINCREMENT"
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	MySQLDriver  = "mysql"
+	SQLiteDriver = "sqlite"
+)
+
+// Store is the object store backing the logsviewer API.
+// It is implemented by DatabaseInstance on top of either MySQL or SQLite.
+type Store interface {
+	// InitTables applies any pending schema migrations
+	InitTables() error
+	// Ping verifies the database connection is alive
+	Ping(ctx context.Context) error
+	Shutdown() error
+	// InCluster scopes the store to the objects of a cluster, or to every
+	// cluster when empty
+	InCluster(cluster string) Store
+
+	StorePod(pod *Pod) error
+	StoreNode(node *Node) error
+	StoreVm(vm *VirtualMachine) error
+	StoreVmi(vmi *VirtualMachineInstance) error
+	StoreVmiMigration(vmim *VirtualMachineInstanceMigration) error
+	StorePVC(pvc *PersistentVolumeClaim) error
+	StoreSubscription(sub *Subscription) error
+	StoreCSV(csv *ClusterServiceVersion) error
+	StoreInstallPlan(plan *InstallPlan) error
+	StoreEvent(event *Event) error
+	StoreImportedMustGather(img *ImportedMustGather) error
+	// StoreSnapshot records the state of an object in an import and tells
+	// whether it is the latest state known of the object
+	StoreSnapshot(snapshot *Snapshot) (bool, error)
+
+	GetPods(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetNodes(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetVms(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetVmis(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetVmiMigrations(page int, perPage int, vmiDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetPVCs(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetSubscriptions(page int, perPage int, queryDetails *GenericQueryDetails) (map[string]interface{}, error)
+	GetPodPVCs(podUUID string) (map[string]interface{}, error)
+	GetVMIPVCs(vmiUUID string) (map[string]interface{}, error)
+	GetResourceStats() (map[string]interface{}, error)
+
+	GetPodObject(podUUID string) (*k8sv1.Pod, error)
+	GetNodeObject(nodeUUID string) (*k8sv1.Node, error)
+	GetVMObject(vmUUID string) (*kubevirtv1.VirtualMachine, error)
+	GetVMIObject(vmiUUID string) (*kubevirtv1.VirtualMachineInstance, error)
+	GetPVCObject(pvcUUID string) (*k8sv1.PersistentVolumeClaim, error)
+
+	GetPodQueryParams(podUUID string) (QueryResults, error)
+	GetVMIQueryParams(vmiUUID string, nodeName string) (QueryResults, error)
+	GetFullVMIHistoryQueryParams(vmiUUID string) (QueryResults, error)
+	GetMigrationQueryParams(migrationUUID string) (QueryResults, error)
+	GetNodeQueryParams(nodeName string) (QueryResults, error)
+	GetPVCQueryParams(pvcUUID string) (QueryResults, error)
+	GetSubscriptionQueryParams(subscriptionUUID string) (QueryResults, error)
+	GetCSVQueryParams(csvUUID string) (QueryResults, error)
+	GetNamespaceQueryParams(namespace string) (QueryResults, error)
+
+	GetNamespaceSummary(namespace string) (*NamespaceSummary, error)
+	GetVMITimeline(vmiUUID string) ([]TimelineEntry, error)
+	Search(term string, kinds []string, limit int) ([]SearchResult, error)
+	Resolve(value string) ([]ResolvedObject, error)
+	GetObjectHistory(kind string, uid string) ([]Snapshot, error)
+	DiffImports(fromID int, toID int, kinds []string) (*ImportDiff, error)
+	SearchContent(kind string, search ContentSearch, page int, perPage int) (map[string]interface{}, error)
+
+	ListClusters() ([]Cluster, error)
+	ListImportedMustGather() ([]ImportedMustGather, error)
+	GetImportedMustGather(name string) (*ImportedMustGather, bool, error)
+	// DeleteImport deletes an import and the objects only found in it
+	DeleteImport(id int) (*DeletedImport, error)
+	LatestGatherTime() (time.Time, error)
+
+	ListNoiseFilters() ([]NoiseFilter, error)
+	GetNoiseFilter(id int) (*NoiseFilter, error)
+	CreateNoiseFilter(filter *NoiseFilter) error
+	UpdateNoiseFilter(filter *NoiseFilter) error
+	DeleteNoiseFilter(id int) error
+}
+
+var _ Store = &DatabaseInstance{}
+
+// StoreOptions selects the storage backend and how to reach it
+type StoreOptions struct {
+	Driver     string
+	MySQL      MySQLOptions
+	SQLitePath string
+}
+
+// IsNotFound tells whether err comes from a lookup that matched no object
+func IsNotFound(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// NewStore connects to the storage backend selected by the options
+func NewStore(opts StoreOptions) (Store, error) {
+	switch opts.Driver {
+	case MySQLDriver:
+		return NewMySQLDatabaseInstance(opts.MySQL)
+	case SQLiteDriver:
+		return NewSQLiteDatabaseInstance(opts.SQLitePath)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", opts.Driver)
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// LatestGatherTime returns when the most recent must-gather was collected,
+// or a zero time when it isn't known
+func (d *DatabaseInstance) LatestGatherTime() (time.Time, error) {
+	var gatherTime time.Time
+	query := d.scoped(newSelectQuery("select gatherTime from importedmustgathers")).order("gatherTime desc")
+	statement, args := query.limited(1, 0)
+	err := d.conn().QueryRow(statement, args...).Scan(&gatherTime)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return gatherTime, err
+}
+
+// earliestCreation returns the first creationTime of the rows of query, or
+// a zero time when none has one. Unknown creations are stored as the zero time.
+func (d *DatabaseInstance) earliestCreation(query *selectQuery) (time.Time, error) {
+	var creationTime time.Time
+	statement, args := query.where("creationTime > ?", "1970-01-01 00:00:00").order("creationTime ASC").limited(1, 0)
+	err := d.conn().QueryRow(statement, args...).Scan(&creationTime)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return creationTime, err
+}
+
+// podEndTime is when the pod was deleted or, once it has completed, its
+// last status transition. It's zero for a pod that is still around.
+func podEndTime(content json.RawMessage) time.Time {
+	var pod k8sv1.Pod
+	if err := json.Unmarshal(content, &pod); err != nil {
+		return time.Time{}
+	}
+	if pod.DeletionTimestamp != nil {
+		return pod.DeletionTimestamp.Time
+	}
+	if pod.Status.Phase != k8sv1.PodSucceeded && pod.Status.Phase != k8sv1.PodFailed {
+		return time.Time{}
+	}
+
+	end := time.Time{}
+	for _, condition := range pod.Status.Conditions {
+		if condition.LastTransitionTime.After(end) {
+			end = condition.LastTransitionTime.Time
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil && status.State.Terminated.FinishedAt.After(end) {
+			end = status.State.Terminated.FinishedAt.Time
+		}
+	}
+	return end
+}
+
+// vmiEndTime is when the VMI was deleted or reached the Succeeded or Failed
+// phase. It's zero for a VMI that is still around.
+func vmiEndTime(content json.RawMessage) time.Time {
+	var vmi kubevirtv1.VirtualMachineInstance
+	if err := json.Unmarshal(content, &vmi); err != nil {
+		return time.Time{}
+	}
+	if vmi.DeletionTimestamp != nil {
+		return vmi.DeletionTimestamp.Time
+	}
+	for _, transition := range vmi.Status.PhaseTransitionTimestamps {
+		if transition.Phase == kubevirtv1.Succeeded || transition.Phase == kubevirtv1.Failed {
+			return transition.PhaseTransitionTimestamp.Time
+		}
+	}
+	return time.Time{}
+}
+
+// nodeReadyTransitionTime is when the Ready condition of the node last
+// changed, or the node creation when it has no such condition
+func nodeReadyTransitionTime(content json.RawMessage) time.Time {
+	var node k8sv1.Node
+	if err := json.Unmarshal(content, &node); err != nil {
+		return time.Time{}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == k8sv1.NodeReady {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return node.CreationTimestamp.Time
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeQueryStart(t *testing.T) {
+	store := newTestStore(t)
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ready := created.Add(48 * time.Hour)
+	launched := created.Add(2 * time.Hour)
+
+	readyNode := k8sv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ready"}}
+	readyNode.Status.Conditions = []k8sv1.NodeCondition{{Type: k8sv1.NodeReady, LastTransitionTime: metav1.NewTime(ready)}}
+	for _, node := range []*Node{
+		{Name: "ready", SystemUUID: "ready-uid", Content: testContent(t, readyNode)},
+		{Name: "created", SystemUUID: "created-uid", Content: testContent(t, k8sv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "created", CreationTimestamp: metav1.NewTime(created)}})},
+		{Name: "bare", SystemUUID: "bare-uid", Content: testContent(t, testMetadata("bare", "", "bare-uid"))},
+		{Name: "empty", SystemUUID: "empty-uid", Content: testContent(t, testMetadata("empty", "", "empty-uid"))},
+	} {
+		if err := store.StoreNode(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pod := range []*Pod{
+		{Name: "virt-handler-abcde", UUID: "handler-uid", NodeName: "bare", CreationTime: metav1.NewTime(launched.Add(time.Hour))},
+		{Name: "virt-launcher-vm-abcde", UUID: "launcher-uid", NodeName: "bare", CreationTime: metav1.NewTime(launched)},
+		// an unknown creation is stored as the zero time
+		{Name: "virt-launcher-vm-fghij", UUID: "unknown-uid", NodeName: "bare"},
+		// only the virt pods tell when the node ran workloads
+		{Name: "kube-proxy-abcde", UUID: "proxy-uid", NodeName: "bare", CreationTime: metav1.NewTime(created)},
+		{Name: "virt-handler-fghij", UUID: "other-handler-uid", NodeName: "ready", CreationTime: metav1.NewTime(launched)},
+	} {
+		pod.Namespace, pod.Content = "kubevirt", testContent(t, testMetadata(pod.Name, "kubevirt", pod.UUID))
+		if err := store.StorePod(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for node, want := range map[string]time.Time{
+		"ready":   ready,
+		"created": created,
+		"bare":    launched,
+		"empty":   {},
+	} {
+		t.Run(node, func(t *testing.T) {
+			data, err := store.GetNodeQueryParams(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !data.StartTimestamp.Equal(want) {
+				t.Errorf("start = %v, want %v", data.StartTimestamp, want)
+			}
+		})
+	}
+}
+
+func TestNodeQueryAcrossClusters(t *testing.T) {
+	store := newTestStore(t)
+	for _, cluster := range []string{"cluster-a", "cluster-b"} {
+		scoped := store.InCluster(cluster).(*DatabaseInstance)
+		node := &Node{Name: "node-a", SystemUUID: cluster + "-node-a", Content: testContent(t, testNode("node-a", "RHCOS 4.13"))}
+		if err := scoped.StoreNode(node); err != nil {
+			t.Fatal(err)
+		}
+		uid := cluster + "-handler"
+		pod := &Pod{Name: "virt-handler-" + cluster, Namespace: "kubevirt", UUID: uid, NodeName: "node-a",
+			CreationTime: metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)), Content: testContent(t, testMetadata("virt-handler-"+cluster, "kubevirt", uid))}
+		if err := scoped.StorePod(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.InCluster("cluster-b").(*DatabaseInstance).StoreNode(&Node{Name: "node-b", SystemUUID: "node-b-uid",
+		Content: testContent(t, testNode("node-b", "RHCOS 4.13"))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetNodeQueryParams("node-a"); !errors.Is(err, ErrMixedClusters) {
+		t.Errorf("querying a node of two clusters returned %v, want %v", err, ErrMixedClusters)
+	}
+	data, err := store.InCluster("cluster-a").GetNodeQueryParams("node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Pod{{Name: "virt-handler-cluster-a", UUID: "cluster-a-handler", NodeName: "node-a"}}; !reflect.DeepEqual(data.InvolvedVirtHandlers, want) {
+		t.Errorf("virt-handlers %v, want %v", data.InvolvedVirtHandlers, want)
+	}
+	// a node of a single cluster is found without picking it
+	if _, err := store.GetNodeQueryParams("node-b"); err != nil {
+		t.Errorf("querying node-b returned %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"logsviewer/pkg/backend/log"
+)
+
+// GetVMITimeline merges the phase transitions and conditions of a VMI, the
+// creation and termination of its virt-launchers, its migrations and the
+// events of all of them into a timeline ordered by time. Entries that don't
+// name a node are attributed the node of the launcher running at the time.
+func (d *DatabaseInstance) GetVMITimeline(vmiUUID string) ([]TimelineEntry, error) {
+	var name, namespace string
+	var content json.RawMessage
+	err := d.queryRow(d.scoped(newSelectQuery("select name, namespace, content from vmis")).where("uuid = ?", vmiUUID)).
+		Scan(&name, &namespace, &content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Log.Println("getVMITimeline can't find any VMIs with this uuid: ", vmiUUID)
+		}
+		return nil, err
+	}
+	var vmi kubevirtv1.VirtualMachineInstance
+	if err := json.Unmarshal(content, &vmi); err != nil {
+		return nil, err
+	}
+
+	timeline := &timelineBuilder{}
+	vmiEntry := TimelineEntry{Source: "VirtualMachineInstance", ObjectName: name, ObjectUID: vmiUUID}
+	timeline.add(vmi.CreationTimestamp.Time, vmiEntry, "Created", "", "")
+	for _, transition := range vmi.Status.PhaseTransitionTimestamps {
+		timeline.add(transition.PhaseTransitionTimestamp.Time, vmiEntry, "PhaseTransition", string(transition.Phase), "")
+	}
+	for _, condition := range vmi.Status.Conditions {
+		message := condition.Message
+		if condition.Reason != "" {
+			message = condition.Reason + ": " + message
+		}
+		timeline.add(condition.LastTransitionTime.Time, vmiEntry, "Condition",
+			fmt.Sprintf("%s=%s", condition.Type, condition.Status), message)
+	}
+	if vmi.DeletionTimestamp != nil {
+		timeline.add(vmi.DeletionTimestamp.Time, vmiEntry, "Deleted", "", "")
+	}
+
+	uids := []string{vmiUUID}
+	launchers, err := d.timelineLaunchers(vmiUUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, launcher := range launchers {
+		uids = append(uids, launcher.UUID)
+		entry := TimelineEntry{Source: "Pod", ObjectName: launcher.Name, ObjectUID: launcher.UUID, Node: launcher.NodeName}
+		timeline.add(launcher.CreationTime.Time, entry, "PodCreated", "", "")
+		if end := podEndTime(launcher.Content); !end.IsZero() {
+			timeline.add(end, entry, "PodTerminated", "", "")
+		}
+	}
+
+	migrations, err := d.timelineMigrations(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	// the VMI keeps the state of its last migration, even once the migration object is gone
+	if state := vmi.Status.MigrationState; state != nil && !containsMigration(migrations, string(state.MigrationUID)) {
+		migration := kubevirtv1.VirtualMachineInstanceMigration{}
+		migration.UID = state.MigrationUID
+		migration.Status.MigrationState = state
+		migrations = append(migrations, migration)
+	}
+	for _, migration := range migrations {
+		uids = append(uids, string(migration.UID))
+		entry := TimelineEntry{Source: "VirtualMachineInstanceMigration", ObjectName: migration.Name, ObjectUID: string(migration.UID)}
+		state := migration.Status.MigrationState
+		if state == nil {
+			timeline.add(migration.CreationTimestamp.Time, entry, "MigrationCreated", string(migration.Status.Phase), "")
+			continue
+		}
+		message := fmt.Sprintf("from %s to %s", state.SourceNode, state.TargetNode)
+		start := migration.CreationTimestamp.Time
+		if state.StartTimestamp != nil {
+			start = state.StartTimestamp.Time
+		}
+		entry.Node = state.SourceNode
+		timeline.add(start, entry, "MigrationStarted", string(migration.Status.Phase), message)
+		if state.EndTimestamp != nil {
+			result := "Succeeded"
+			if state.Failed {
+				result = "Failed"
+			} else if !state.Completed {
+				result = "Ended"
+			}
+			entry.Node = state.TargetNode
+			timeline.add(state.EndTimestamp.Time, entry, "MigrationEnded", result, message)
+		}
+	}
+
+	events, err := d.queryEvents(d.scoped(newSelectQuery(eventColumns)).
+		where("involvedUid IN ("+placeholders(len(uids))+") or (namespace = ? and involvedKind = ? and involvedName = ?)",
+			append(toArgs(uids), namespace, "VirtualMachineInstance", name)...), -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		entry := TimelineEntry{Source: "Event", ObjectName: event.InvolvedKind + "/" + event.InvolvedName, ObjectUID: event.InvolvedUID}
+		message := event.Message
+		if event.Count > 1 {
+			message = fmt.Sprintf("%s (x%d until %s)", message, event.Count, event.LastTimestamp.UTC().Format(time.RFC3339))
+		}
+		timeline.add(event.FirstTimestamp.Time, entry, event.Type, event.Reason, message)
+	}
+
+	return timeline.sorted(launchers), nil
+}
+
+func containsMigration(migrations []kubevirtv1.VirtualMachineInstanceMigration, uid string) bool {
+	for _, migration := range migrations {
+		if string(migration.UID) == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// timelineLaunchers lists the virt-launchers of a VMI with their content, by creation
+func (d *DatabaseInstance) timelineLaunchers(vmiUUID string) ([]Pod, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	query := d.scoped(newSelectQuery("select uuid, name, nodeName, creationTime, content from pods")).
+		where("createdBy = ?", vmiUUID).
+		order("creationTime ASC")
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pods := []Pod{}
+	for rows.Next() {
+		pod := Pod{}
+		var nodeName sql.NullString
+		if err := rows.Scan(&pod.UUID, &pod.Name, &nodeName, &pod.CreationTime.Time, &pod.Content); err != nil {
+			return nil, err
+		}
+		pod.NodeName = nodeName.String
+		pods = append(pods, pod)
+	}
+	return pods, rows.Err()
+}
+
+// timelineMigrations lists the migrations of a VMI
+func (d *DatabaseInstance) timelineMigrations(vmiName string, namespace string) ([]kubevirtv1.VirtualMachineInstanceMigration, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, 5*time.Second)
+	defer cancel()
+
+	query := d.scoped(newSelectQuery("select content from vmimigrations")).
+		where("vmiName = ?", vmiName).
+		where("namespace = ?", namespace)
+	rows, err := d.conn().QueryContext(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	migrations := []kubevirtv1.VirtualMachineInstanceMigration{}
+	for rows.Next() {
+		var content json.RawMessage
+		if err := rows.Scan(&content); err != nil {
+			return nil, err
+		}
+		migration := kubevirtv1.VirtualMachineInstanceMigration{}
+		if err := json.Unmarshal(content, &migration); err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, rows.Err()
+}
+
+type timelineBuilder struct {
+	entries []TimelineEntry
+}
+
+// add appends an entry based on template, entries without a time are dropped
+func (b *timelineBuilder) add(at time.Time, template TimelineEntry, entryType string, reason string, message string) {
+	if at.IsZero() {
+		return
+	}
+	template.Time = at.UTC()
+	template.Type = entryType
+	template.Reason = reason
+	template.Message = message
+	b.entries = append(b.entries, template)
+}
+
+// sorted orders the entries by time and fills in the node of the launcher
+// that was created last before each entry without one
+func (b *timelineBuilder) sorted(launchers []Pod) []TimelineEntry {
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.entries[i].Time.Before(b.entries[j].Time)
+	})
+	for i := range b.entries {
+		if b.entries[i].Node != "" {
+			continue
+		}
+		for _, launcher := range launchers {
+			if launcher.CreationTime.After(b.entries[i].Time) {
+				break
+			}
+			b.entries[i].Node = launcher.NodeName
+		}
+	}
+	if b.entries == nil {
+		return []TimelineEntry{}
+	}
+	return b.entries
+}
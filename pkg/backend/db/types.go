@@ -114,15 +114,254 @@ type (
 		Content      json.RawMessage `json:"content"`
 	}
 
+	// ClusterServiceVersion is an operator version installed by OLM
+	ClusterServiceVersion struct {
+		UUID string `json:"uuid"`
+
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Version   string `json:"version"`
+		Phase     string `json:"phase"`
+		Replaces  string `json:"replaces"`
+
+		CreationTime       metav1.Time     `json:"creationTime"`
+		LastTransitionTime metav1.Time     `json:"lastTransitionTime"`
+		Content            json.RawMessage `json:"content"`
+	}
+
+	// InstallPlan is the plan OLM followed to install or upgrade CSVs,
+	// CSVNames is a comma separated list
+	InstallPlan struct {
+		UUID string `json:"uuid"`
+
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Phase     string `json:"phase"`
+		CSVNames  string `json:"csvNames"`
+		Approved  bool   `json:"approved"`
+
+		CreationTime       metav1.Time     `json:"creationTime"`
+		LastTransitionTime metav1.Time     `json:"lastTransitionTime"`
+		Content            json.RawMessage `json:"content"`
+	}
+
+	// Event is a Kubernetes event, Count is how many times it was reported
+	// between FirstTimestamp and LastTimestamp
+	Event struct {
+		UUID string `json:"uuid"`
+
+		Name         string `json:"name"`
+		Namespace    string `json:"namespace"`
+		InvolvedKind string `json:"involvedKind"`
+		InvolvedName string `json:"involvedName"`
+		InvolvedUID  string `json:"involvedUid"`
+		Type         string `json:"type"`
+		Reason       string `json:"reason"`
+		Message      string `json:"message"`
+		Count        int    `json:"count"`
+
+		FirstTimestamp metav1.Time     `json:"firstTimestamp"`
+		LastTimestamp  metav1.Time     `json:"lastTimestamp"`
+		Content        json.RawMessage `json:"content,omitempty"`
+	}
+
+	// ObjectHealth tells whether an object is healthy, Reason explains its status
+	ObjectHealth struct {
+		UUID    string `json:"uuid"`
+		Name    string `json:"name"`
+		Status  string `json:"status"`
+		Reason  string `json:"reason,omitempty"`
+		Healthy bool   `json:"healthy"`
+	}
+
+	// KindHealth counts the objects of a kind by status and lists the unhealthy ones
+	KindHealth struct {
+		Total     int            `json:"total"`
+		ByStatus  map[string]int `json:"byStatus"`
+		Unhealthy []ObjectHealth `json:"unhealthy"`
+	}
+
+	// EventsSummary counts the events by type and lists the latest warnings
+	EventsSummary struct {
+		Total    int            `json:"total"`
+		ByType   map[string]int `json:"byType"`
+		Warnings []Event        `json:"warnings"`
+	}
+
+	NamespaceSummary struct {
+		Namespace  string        `json:"namespace"`
+		Healthy    bool          `json:"healthy"`
+		VMs        KindHealth    `json:"vms"`
+		VMIs       KindHealth    `json:"vmis"`
+		Pods       KindHealth    `json:"pods"`
+		PVCs       KindHealth    `json:"pvcs"`
+		Migrations KindHealth    `json:"vmimigrations"`
+		Events     EventsSummary `json:"events"`
+	}
+
+	// TimelineEntry is a step in the life of a VMI. Source is the kind of
+	// the object the step comes from, the object is the one involved in
+	// an event.
+	TimelineEntry struct {
+		Time       time.Time `json:"time"`
+		Source     string    `json:"source"`
+		Type       string    `json:"type"`
+		Reason     string    `json:"reason,omitempty"`
+		Message    string    `json:"message,omitempty"`
+		Node       string    `json:"node,omitempty"`
+		ObjectName string    `json:"objectName,omitempty"`
+		ObjectUID  string    `json:"objectUid,omitempty"`
+	}
+
+	// SearchResult is an object matching a search, Field is the field that
+	// matched and Score how well
+	SearchResult struct {
+		Kind      string `json:"kind"`
+		UID       string `json:"uid"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+		Node      string `json:"node,omitempty"`
+		Field     string `json:"field"`
+		Score     int    `json:"score"`
+		Link      string `json:"link,omitempty"`
+	}
+
+	// Owner is an owner reference of a resolved object
+	Owner struct {
+		Kind       string `json:"kind"`
+		Name       string `json:"name"`
+		UID        string `json:"uid"`
+		Controller bool   `json:"controller"`
+	}
+
+	// ResolvedObject is an object a UID or an IP address identifies,
+	// MatchedBy tells which of its fields matched
+	ResolvedObject struct {
+		Kind      string  `json:"kind"`
+		UID       string  `json:"uid"`
+		Name      string  `json:"name"`
+		Namespace string  `json:"namespace,omitempty"`
+		Node      string  `json:"node,omitempty"`
+		MatchedBy string  `json:"matchedBy"`
+		Owners    []Owner `json:"owners"`
+
+		metadataUID string
+		content     json.RawMessage
+	}
+
 	ImportedMustGather struct {
+		ID           int       `json:"id"`
 		Name         string    `json:"name"`
 		ImportTime   time.Time `json:"importTime"`
 		GatherTime   time.Time `json:"gatherTime"`
 		InsightsData string    `json:"insightsData"`
+		// Cluster identifies the gathered cluster by its ClusterVersion ID or,
+		// without one, its infrastructure name
+		Cluster     string `json:"cluster"`
+		ClusterName string `json:"clusterName"`
+	}
+
+	// Cluster sums up the imports of a cluster
+	Cluster struct {
+		ID              string    `json:"id"`
+		Name            string    `json:"name"`
+		Imports         int       `json:"imports"`
+		FirstGatherTime time.Time `json:"firstGatherTime"`
+		LastGatherTime  time.Time `json:"lastGatherTime"`
+	}
+
+	// DeletedImport tells what went with a deleted import
+	DeletedImport struct {
+		Import ImportedMustGather `json:"import"`
+		// Objects counts the rows deleted by kind, the objects also found
+		// in another import are kept
+		Objects map[string]int `json:"objects"`
+		// Restored counts by kind the objects kept whose latest state was
+		// the one of the import, they're back to their latest remaining state
+		Restored map[string]int `json:"restored"`
+		Events   int            `json:"events"`
+		// RemovedPodUIDs are the pods that were only found in the import
+		RemovedPodUIDs []string `json:"-"`
+	}
+
+	// Snapshot is the state of an object in one import
+	Snapshot struct {
+		Kind       string          `json:"kind"`
+		UUID       string          `json:"uuid"`
+		ImportID   int             `json:"importId"`
+		ImportName string          `json:"importName,omitempty"`
+		Name       string          `json:"name"`
+		Namespace  string          `json:"namespace,omitempty"`
+		GatherTime time.Time       `json:"gatherTime"`
+		Content    json.RawMessage `json:"content"`
+	}
+
+	// ObjectRef names an object of an import
+	ObjectRef struct {
+		Kind      string `json:"kind"`
+		UID       string `json:"uid"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+	}
+
+	// ValueChange is a value that differs between two imports
+	ValueChange struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	// FieldChange is a field of an object that differs between two imports,
+	// the values are rendered as YAML and left out when the field is missing
+	FieldChange struct {
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+		To   string `json:"to,omitempty"`
+	}
+
+	// ObjectChange describes how an object found in two imports changed
+	ObjectChange struct {
+		ObjectRef
+		Phase *ValueChange `json:"phase,omitempty"`
+		Node  *ValueChange `json:"node,omitempty"`
+		// Restarts counts the container restarts of a pod in between
+		Restarts int           `json:"restarts,omitempty"`
+		Fields   []FieldChange `json:"fields"`
+	}
+
+	// InsightsFinding is a report of the insights rules run on an import
+	InsightsFinding struct {
+		Key       string          `json:"key"`
+		Type      string          `json:"type,omitempty"`
+		Component string          `json:"component,omitempty"`
+		Details   json.RawMessage `json:"details,omitempty"`
+	}
+
+	// ImportDiff compares the objects and insights findings of two imports
+	ImportDiff struct {
+		From    ImportedMustGather `json:"from"`
+		To      ImportedMustGather `json:"to"`
+		Added   []ObjectRef        `json:"added"`
+		Removed []ObjectRef        `json:"removed"`
+		Changed []ObjectChange     `json:"changed"`
+		// Appeared and Resolved are the insights findings only reported
+		// by the later and the earlier import
+		Appeared []InsightsFinding `json:"appeared"`
+		Resolved []InsightsFinding `json:"resolved"`
+		Warnings []string          `json:"warnings,omitempty"`
+	}
+
+	// NoiseFilter excludes the log lines whose msg contains Phrase
+	// from every correlation query, while it's enabled
+	NoiseFilter struct {
+		ID          int    `json:"id"`
+		Phrase      string `json:"phrase"`
+		Description string `json:"description"`
+		Enabled     bool   `json:"enabled"`
 	}
 
 	QueryResults struct {
 		Namespace             string
+		NodeName              string
 		SourcePodUUID         string
 		TargetPodUUID         string
 		VMIUUID               string
@@ -136,6 +375,18 @@ type (
 		PVCs                  []string
 		InvolvedVirtLaunchers []Pod
 		InvolvedVirtHandlers  []Pod
+		PVCName               string
+		DataVolumeUUID        string
+		InvolvedCDIPods       []Pod
+		InvolvedCSIPods       []Pod
+		CSVNames              []string
+		InstallPlanNames      []string
+		InvolvedOperatorPods  []Pod
+		InvolvedPods          []Pod
+		VMIUUIDs              []string
+		// Warnings name the components that couldn't be resolved, the
+		// query is built without them
+		Warnings []string
 	}
 
 	GenericQueryDetails struct {
@@ -144,5 +395,16 @@ type (
 		Namespace string
 		Yaml      bool
 		Status    string
+		// Sort orders a list, by name when nil
+		Sort *ListSort
+		// After is the primary key of the last row of the previous page
+		After string
+	}
+
+	// ContentSearch selects objects by their stored content
+	ContentSearch struct {
+		Text  string
+		Path  *ContentPath
+		Value string
 	}
 )
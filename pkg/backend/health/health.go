@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Check reports whether a single component is usable
+type Check func(ctx context.Context) error
+
+type component struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of every registered component
+type Checker struct {
+	timeout    time.Duration
+	components []component
+}
+
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.components = append(c.components, component{name: name, check: check})
+}
+
+// Run checks all components concurrently
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: map[string]ComponentStatus{},
+	}
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, comp := range c.components {
+		wg.Add(1)
+		go func(comp component) {
+			defer wg.Done()
+			status := ComponentStatus{Status: StatusOK}
+			if err := comp.check(ctx); err != nil {
+				status = ComponentStatus{Status: StatusFailed, Error: err.Error()}
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			report.Components[comp.name] = status
+			if status.Status != StatusOK {
+				report.Status = StatusFailed
+			}
+		}(comp)
+	}
+	wg.Wait()
+	return report
+}
+
+// ServeHTTP reports every component, answering 503 when any of them failed
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	if report.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+}
+
+// getJSON fetches url and decodes its JSON body into out
+func getJSON(ctx context.Context, url string, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response %s from %s", response.Status, url)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func apiURL(baseURL string, path string) string {
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// Elasticsearch checks the cluster health, a yellow cluster is expected
+// on the single node deployment and counts as ready.
+func Elasticsearch(baseURL string) Check {
+	return func(ctx context.Context) error {
+		health := struct {
+			Status string `json:"status"`
+		}{}
+		if err := getJSON(ctx, apiURL(baseURL, "/_cluster/health"), &health); err != nil {
+			return err
+		}
+		if health.Status != "green" && health.Status != "yellow" {
+			return fmt.Errorf("cluster health is %q", health.Status)
+		}
+		return nil
+	}
+}
+
+// Kibana checks the overall level reported by the Kibana status API
+func Kibana(baseURL string) Check {
+	return func(ctx context.Context) error {
+		status := struct {
+			Status struct {
+				Overall struct {
+					Level string `json:"level"`
+				} `json:"overall"`
+			} `json:"status"`
+		}{}
+		if err := getJSON(ctx, apiURL(baseURL, "/api/status"), &status); err != nil {
+			return err
+		}
+		if level := status.Status.Overall.Level; level != "available" {
+			return fmt.Errorf("kibana status is %q", level)
+		}
+		return nil
+	}
+}
+
+// Logstash checks the status reported by the Logstash monitoring API
+func Logstash(baseURL string) Check {
+	return func(ctx context.Context) error {
+		node := struct {
+			Status string `json:"status"`
+		}{}
+		if err := getJSON(ctx, apiURL(baseURL, "/"), &node); err != nil {
+			return err
+		}
+		if node.Status == "red" {
+			return fmt.Errorf("logstash status is %q", node.Status)
+		}
+		return nil
+	}
+}
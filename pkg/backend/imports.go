@@ -0,0 +1,171 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"logsviewer/pkg/backend/correlation"
+	"logsviewer/pkg/backend/db"
+	"logsviewer/pkg/backend/log"
+)
+
+// logsDeleteBatchSize caps the pods matched by a single delete of their
+// logs, below the default clause limit of Elasticsearch
+const logsDeleteBatchSize = 500
+
+// deleteImport deletes an import and what was derived from it: the objects
+// only found in it, its extracted files when they are still around, the
+// enrichment entries of the pods only found in it and the logs it ingested.
+// The database goes first, the rest can't be rolled back and its failures
+// are reported as warnings.
+func (c *app) deleteImport(req *apiRequest) (interface{}, error) {
+	c.dataDirLock.Lock()
+	defer c.dataDirLock.Unlock()
+
+	deleted, err := c.storeDB.DeleteImport(req.integer("id", 0))
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	if err := c.removeExtractedFiles(deleted.Import); err != nil {
+		log.Log.Println("failed to remove the extracted files of ", deleted.Import.Name, " - ", err)
+		warnings = append(warnings, fmt.Sprintf("failed to remove the extracted files: %v", err))
+	}
+	if err := c.removeEnrichmentData(deleted.RemovedPodUIDs); err != nil {
+		log.Log.Println("failed to remove the enrichment data of ", deleted.Import.Name, " - ", err)
+		warnings = append(warnings, fmt.Sprintf("failed to remove the enrichment data: %v", err))
+	}
+	logs, err := c.deleteImportLogs(req.Context(), deleted.Import.Name, deleted.RemovedPodUIDs)
+	if err != nil {
+		log.Log.Println("failed to delete the logs of ", deleted.Import.Name, " - ", err)
+		warnings = append(warnings, fmt.Sprintf("failed to delete the logs: %v", err))
+	}
+
+	return map[string]interface{}{
+		"data":     deleted,
+		"meta":     map[string]interface{}{"logs": logs},
+		"warnings": warnings,
+	}, nil
+}
+
+// removeExtractedFiles empties the data dir when it holds the files of the
+// import, told by their gather time and cluster. Every import replaces the
+// files of the previous one. The enrichment data is kept for the other imports.
+func (c *app) removeExtractedFiles(img db.ImportedMustGather) error {
+	gatherTime, err := getMustGatherTimestamp(c.config.TimestampFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if cluster, _ := getClusterIdentity(c.config.DataDir); !gatherTime.Equal(img.GatherTime) || cluster != img.Cluster {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(c.config.DataDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(c.config.DataDir, entry.Name())
+		if path == filepath.Clean(c.config.EnrichmentDataFile) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEnrichmentData drops the entries of the pods from the logstash
+// enrichment dictionary
+func (c *app) removeEnrichmentData(podUIDs []string) error {
+	content, err := ioutil.ReadFile(c.config.EnrichmentDataFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	lookupData := map[string]EnrichmentData{}
+	if err := json.Unmarshal(content, &lookupData); err != nil {
+		return err
+	}
+
+	uids := map[string]bool{}
+	for _, uid := range podUIDs {
+		uids[uid] = true
+	}
+	for key, data := range lookupData {
+		if uids[data.UID] {
+			delete(lookupData, key)
+		}
+	}
+
+	content, err = json.Marshal(lookupData)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.config.EnrichmentDataFile, content, 0644)
+}
+
+// deleteImportLogs deletes the log lines tagged with the import and those of
+// the pods from Elasticsearch, and counts them
+func (c *app) deleteImportLogs(ctx context.Context, name string, podUIDs []string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	url := strings.TrimSuffix(c.config.ElasticsearchURL, "/") + "/" + correlation.Index + "/_delete_by_query?conflicts=proceed"
+	total := 0
+	// a request is sent even without pods, the first one matches the lines
+	// of the import as well
+	for start := 0; start == 0 || start < len(podUIDs); start += logsDeleteBatchSize {
+		end := start + logsDeleteBatchSize
+		if end > len(podUIDs) {
+			end = len(podUIDs)
+		}
+		tag := name
+		if start > 0 {
+			tag = ""
+		}
+		body, err := json.Marshal(correlation.ImportLogs(tag, podUIDs[start:end]))
+		if err != nil {
+			return total, err
+		}
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return total, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return total, err
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+		response.Body.Close()
+		if err != nil {
+			return total, err
+		}
+		if response.StatusCode != http.StatusOK {
+			return total, fmt.Errorf("unexpected response %s from %s", response.Status, url)
+		}
+		result := struct {
+			Deleted int `json:"deleted"`
+		}{}
+		if err := json.Unmarshal(content, &result); err != nil {
+			return total, err
+		}
+		total += result.Deleted
+	}
+	return total, nil
+}
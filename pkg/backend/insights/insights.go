@@ -2,17 +2,20 @@ package insights
 
 import (
 	"os/exec"
+
+	"logsviewer/pkg/backend/config"
 )
 
 type Insights struct {
 	binaryPath string
+	plugin     string
 }
 
-func New(binaryPath string) *Insights {
-	return &Insights{binaryPath: binaryPath}
+func New(cfg config.InsightsConfig) *Insights {
+	return &Insights{binaryPath: cfg.BinaryPath, plugin: cfg.Plugin}
 }
 
 func (i Insights) Exec(mustGatherPath string) ([]byte, error) {
-	cmd := exec.Command(i.binaryPath, "run", "-p", "ccx_rules_ocp", "-f", "json", mustGatherPath)
+	cmd := exec.Command(i.binaryPath, "run", "-p", i.plugin, "-f", "json", mustGatherPath)
 	return cmd.Output()
 }
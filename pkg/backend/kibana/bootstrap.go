@@ -0,0 +1,116 @@
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"logsviewer/pkg/backend/log"
+)
+
+const (
+	DataViewID = "cnvlogs-default"
+
+	maxRetryInterval = 1 * time.Minute
+)
+
+// Bootstrapper creates the cnvlogs data view and makes it the Kibana default
+type Bootstrapper struct {
+	baseURL string
+	client  *http.Client
+	done    atomic.Bool
+}
+
+func NewBootstrapper(baseURL string) *Bootstrapper {
+	return &Bootstrapper{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run retries the bootstrap with a growing interval until it succeeds
+// or the context is cancelled.
+func (b *Bootstrapper) Run(ctx context.Context) {
+	interval := 2 * time.Second
+	for {
+		err := b.bootstrap(ctx)
+		if err == nil {
+			log.Log.Println("kibana data view is ready")
+			b.done.Store(true)
+			return
+		}
+		log.Log.Println("kibana bootstrap failed, retrying in", interval, "-", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+// Check reports whether the bootstrap has completed
+func (b *Bootstrapper) Check(ctx context.Context) error {
+	if !b.done.Load() {
+		return errors.New("data view " + DataViewID + " has not been created yet")
+	}
+	return nil
+}
+
+func (b *Bootstrapper) bootstrap(ctx context.Context) error {
+	status, _, err := b.do(ctx, http.MethodGet, "/api/data_views/data_view/"+DataViewID, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		if err := b.post(ctx, "/api/data_views/data_view", `{"data_view": {"title": "cnvlogs*", "timeFieldName":"@timestamp", "id":"`+DataViewID+`"}}`); err != nil {
+			return err
+		}
+	} else if status != http.StatusOK {
+		return fmt.Errorf("unexpected status %d looking up data view %s", status, DataViewID)
+	}
+
+	return b.post(ctx, "/api/data_views/default", `{"data_view_id": "`+DataViewID+`"}`)
+}
+
+func (b *Bootstrapper) post(ctx context.Context, path string, jsonData string) error {
+	status, body, err := b.do(ctx, http.MethodPost, path, []byte(jsonData))
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("POST %s returned %d: %s", path, status, body)
+	}
+	return nil
+}
+
+func (b *Bootstrapper) do(ctx context.Context, method string, path string, jsonData []byte) (int, string, error) {
+	request, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, "", err
+	}
+	request.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	request.Header.Add("kbn-xsrf", "true")
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return response.StatusCode, string(body), nil
+}
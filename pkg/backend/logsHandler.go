@@ -19,9 +19,11 @@ import (
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	yamlv3 "gopkg.in/yaml.v3"
 	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 
+	"logsviewer/pkg/backend/config"
 	"logsviewer/pkg/backend/db"
 	"logsviewer/pkg/backend/log"
 )
@@ -48,7 +50,8 @@ type Status struct {
 }
 
 type OwnerReference struct {
-	UID string
+	UID  string
+	Kind string
 }
 
 type EnrichmentData struct {
@@ -56,16 +59,28 @@ type EnrichmentData struct {
 	HostIP          string   `json:"host.ip"`
 	UID             string   `json:"pod.uid"`
 	OwnerReferences []string `json:"pod.ownerReferences,omitempty"`
+	// OwnerKinds resolves the kind of each of the OwnerReferences
+	OwnerKinds []string `json:"pod.ownerKinds,omitempty"`
+	// Cluster tags the logs with the cluster the pod runs in
+	Cluster string `json:"cluster,omitempty"`
+	// Import tags the logs with the name of the import ingesting them, so
+	// that deleting the import deletes them
+	Import string `json:"import,omitempty"`
 }
 
 type logsHandler struct {
+	config      *config.Config
 	handlerLock sync.Mutex
 	stopCh      chan struct{}
 	objectStore *db.ObjectStore
 	lookupData  map[string]EnrichmentData
+	// cluster is the cluster of the must-gather being imported
+	cluster string
+	// importName is the name of the must-gather being imported
+	importName string
 }
 
-func NewLogsHandler(storeDB *db.DatabaseInstance) *logsHandler {
+func NewLogsHandler(storeDB db.Store, cfg *config.Config) *logsHandler {
 	lookupData := make(map[string]EnrichmentData)
 	stopCh := make(chan struct{}, 1)
 	objStore := db.NewObjectStore(storeDB)
@@ -73,6 +88,7 @@ func NewLogsHandler(storeDB *db.DatabaseInstance) *logsHandler {
 	go objStore.Run(1, stopCh)
 
 	return &logsHandler{
+		config:      cfg,
 		lookupData:  lookupData,
 		objectStore: objStore,
 		stopCh:      stopCh,
@@ -210,7 +226,7 @@ func unTarGz(srcFile string, targetPath string) error {
 
 		default:
 			log.Log.Fatalf(
-				"uknown header type: %s in %s",
+				"uknown header type: %c in %s",
 				header.Typeflag,
 				header.Name)
 			return err
@@ -223,7 +239,7 @@ func unTarGz(srcFile string, targetPath string) error {
 
 func (l *logsHandler) loadExistingEnrichmentData() error {
 	// read the existing enrichment data file
-	jsonFile, err := os.Open(ENRICHMENT_DATA_FILE)
+	jsonFile, err := os.Open(l.config.EnrichmentDataFile)
 	if err != nil {
 		return err
 	}
@@ -247,10 +263,13 @@ func (l *logsHandler) processEnrichmentData(pod *Pods) {
 		HostName: pod.Spec.NodeName,
 		HostIP:   pod.Status.HostIP,
 		UID:      pod.Metadata.UID,
+		Cluster:  l.cluster,
+		Import:   l.importName,
 	}
 	if pod.Metadata.OwnerReferences != nil {
 		for _, ref := range pod.Metadata.OwnerReferences {
 			enrichmentData.OwnerReferences = append(enrichmentData.OwnerReferences, ref.UID)
+			enrichmentData.OwnerKinds = append(enrichmentData.OwnerKinds, ref.Kind)
 		}
 	}
 	l.lookupData[key] = enrichmentData
@@ -371,22 +390,68 @@ func (l *logsHandler) processImportedMustGather(filename string, insightsData st
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	gatherTime, err := getMustGatherTimestamp()
+	gatherTime, err := getMustGatherTimestamp(l.config.TimestampFile)
 	if err != nil {
 		return err
 	}
 
+	cluster, clusterName := getClusterIdentity(l.config.DataDir)
+	log.Log.Println("must gather ", filename, " is of cluster ", cluster, " ", clusterName)
+	l.cluster = cluster
+	l.importName = filename
+
 	l.objectStore.Add(&db.ImportedMustGather{
 		Name:         filename,
 		ImportTime:   time.Now(),
 		GatherTime:   gatherTime,
 		InsightsData: insightsData,
+		Cluster:      cluster,
+		ClusterName:  clusterName,
 	})
 	return nil
 }
 
-func getMustGatherTimestamp() (time.Time, error) {
-	timestampFile, err := os.Open(fmt.Sprintf("/space/timestamp"))
+// getClusterIdentity identifies the gathered cluster by its ClusterVersion
+// ID, or by its infrastructure name when there is no ClusterVersion. The
+// name is the infrastructure name, a cluster that can't be identified is
+// db.UnknownCluster.
+func getClusterIdentity(dataDir string) (string, string) {
+	clusterVersion := struct {
+		Spec struct {
+			ClusterID string `json:"clusterID"`
+		} `json:"spec"`
+	}{}
+	infrastructure := struct {
+		Status struct {
+			InfrastructureName string `json:"infrastructureName"`
+		} `json:"status"`
+	}{}
+
+	for path, obj := range map[string]interface{}{
+		"cluster-scoped-resources/config.openshift.io/clusterversions/version.yaml": &clusterVersion,
+		"cluster-scoped-resources/config.openshift.io/infrastructures/cluster.yaml": &infrastructure,
+	} {
+		yamlFile, err := ioutil.ReadFile(filepath.Join(dataDir, path))
+		if err != nil {
+			continue
+		}
+		if err := yaml.Unmarshal(yamlFile, obj); err != nil {
+			log.Log.Println("failed to unmarshal ", path, " - ", err)
+		}
+	}
+
+	name := infrastructure.Status.InfrastructureName
+	switch {
+	case clusterVersion.Spec.ClusterID != "":
+		return clusterVersion.Spec.ClusterID, name
+	case name != "":
+		return name, name
+	}
+	return db.UnknownCluster, ""
+}
+
+func getMustGatherTimestamp(path string) (time.Time, error) {
+	timestampFile, err := os.Open(path)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -424,8 +489,7 @@ func (l *logsHandler) processPodYAMLs() error {
 
 	l.loadExistingEnrichmentData()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/namespaces/*/pods/*/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/pods/*/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -446,7 +510,7 @@ func (l *logsHandler) processPodYAMLs() error {
 	}
 
 	js1, _ := json.Marshal(l.lookupData)
-	_ = ioutil.WriteFile(ENRICHMENT_DATA_FILE, js1, 0644)
+	_ = ioutil.WriteFile(l.config.EnrichmentDataFile, js1, 0644)
 
 	log.Log.Println("finished writting lookupData")
 	return nil
@@ -488,8 +552,7 @@ func (l *logsHandler) processVirtualMachineYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachines/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachines/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -504,7 +567,7 @@ func (l *logsHandler) processVirtualMachineYAMLs() error {
 
 	if len(layouts) == 0 {
 
-		combinedYamls, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachines.yaml")
+		combinedYamls, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachines.yaml"))
 		if err != nil {
 			return (err)
 		}
@@ -527,8 +590,7 @@ func (l *logsHandler) processVirtualMachineInstanceYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachineinstances/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachineinstances/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -543,7 +605,7 @@ func (l *logsHandler) processVirtualMachineInstanceYAMLs() error {
 
 	if len(layouts) == 0 {
 
-		combinedYamls, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachineinstances.yaml")
+		combinedYamls, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachineinstances.yaml"))
 		if err != nil {
 			return (err)
 		}
@@ -566,8 +628,7 @@ func (l *logsHandler) processVirtualMachineInstanceMigrationsYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachineinstancemigrations/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachineinstancemigrations/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -582,7 +643,7 @@ func (l *logsHandler) processVirtualMachineInstanceMigrationsYAMLs() error {
 
 	if len(layouts) == 0 {
 
-		combinedYamls, err := filepath.Glob("/space/namespaces/*/kubevirt.io/virtualmachineinstancemigrations.yaml")
+		combinedYamls, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/kubevirt.io/virtualmachineinstancemigrations.yaml"))
 		if err != nil {
 			return (err)
 		}
@@ -603,8 +664,7 @@ func (l *logsHandler) processNodeYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/cluster-scoped-resources/core/nodes/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "cluster-scoped-resources/core/nodes/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -628,8 +688,7 @@ func (l *logsHandler) processPersistentVolumeClaimYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	//TODO: make path configurable
-	layouts, err := filepath.Glob("/space/namespaces/*/core/persistentvolumeclaims/*.yaml")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/core/persistentvolumeclaims/*.yaml"))
 	if err != nil {
 		return (err)
 	}
@@ -644,7 +703,7 @@ func (l *logsHandler) processPersistentVolumeClaimYAMLs() error {
 
 	if len(layouts) == 0 {
 
-		combinedYamls, err := filepath.Glob("/space/namespaces/*/core/persistentvolumeclaims.yaml")
+		combinedYamls, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/core/persistentvolumeclaims.yaml"))
 		if err != nil {
 			return (err)
 		}
@@ -661,11 +720,48 @@ func (l *logsHandler) processPersistentVolumeClaimYAMLs() error {
 	return nil
 }
 
+func (l *logsHandler) processEventYAMLs() error {
+	l.handlerLock.Lock()
+	defer l.handlerLock.Unlock()
+
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/core/events.yaml"))
+	if err != nil {
+		return err
+	}
+
+	for _, filename := range layouts {
+		log.Log.Println("processing events YAML: ", filename)
+		yamlFile, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if err := l.storeEventListData(yamlFile); err != nil {
+			return err
+		}
+	}
+
+	log.Log.Println("finished processing event YAMLs")
+	return nil
+}
+
+func (l *logsHandler) storeEventListData(yamlFile []byte) error {
+	eventList := k8sv1.EventList{}
+
+	if err := yaml.Unmarshal(yamlFile, &eventList); err != nil {
+		return fmt.Errorf("failed to unmarshal events yaml: %w", err)
+	}
+
+	for i := range eventList.Items {
+		l.objectStore.Add(&eventList.Items[i])
+	}
+	return nil
+}
+
 func (l *logsHandler) processSubscriptionsYAMLs() error {
 	l.handlerLock.Lock()
 	defer l.handlerLock.Unlock()
 
-	layouts, err := filepath.Glob("/space/namespaces/*/subscriptions")
+	layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, "namespaces/*/subscriptions"))
 	if err != nil {
 		return err
 	}
@@ -692,6 +788,78 @@ func (l *logsHandler) processSubscriptionsYAMLs() error {
 	return nil
 }
 
+// processOperatorLifecycleYAMLs stores the CSVs and InstallPlans, collected
+// either as a list per namespace or as a file per object
+func (l *logsHandler) processOperatorLifecycleYAMLs() error {
+	l.handlerLock.Lock()
+	defer l.handlerLock.Unlock()
+
+	for _, pattern := range []string{
+		"namespaces/*/clusterserviceversions",
+		"namespaces/*/installplans",
+		"namespaces/*/operators.coreos.com/clusterserviceversions/*.yaml",
+		"namespaces/*/operators.coreos.com/installplans/*.yaml",
+	} {
+		layouts, err := filepath.Glob(filepath.Join(l.config.DataDir, pattern))
+		if err != nil {
+			return err
+		}
+
+		for _, filename := range layouts {
+			log.Log.Println("processing operator lifecycle YAML: ", filename)
+			yamlFile, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			yamlFile = bytes.TrimRight(yamlFile, " \n-")
+
+			if err := l.storeOperatorLifecycleData(yamlFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Log.Println("finished processing operator lifecycle YAMLs")
+	return nil
+}
+
+func (l *logsHandler) storeOperatorLifecycleData(yamlFile []byte) error {
+	typeMeta := metav1.TypeMeta{}
+	if err := yaml.Unmarshal(yamlFile, &typeMeta); err != nil {
+		return fmt.Errorf("failed to unmarshal operator lifecycle yaml: %w", err)
+	}
+
+	switch typeMeta.Kind {
+	case v1alpha1.ClusterServiceVersionKind:
+		csv := v1alpha1.ClusterServiceVersion{}
+		if err := yaml.Unmarshal(yamlFile, &csv); err != nil {
+			return fmt.Errorf("failed to unmarshal csv yaml: %w", err)
+		}
+		l.objectStore.Add(&csv)
+	case v1alpha1.InstallPlanKind:
+		plan := v1alpha1.InstallPlan{}
+		if err := yaml.Unmarshal(yamlFile, &plan); err != nil {
+			return fmt.Errorf("failed to unmarshal install plan yaml: %w", err)
+		}
+		l.objectStore.Add(&plan)
+	case "List", "ClusterServiceVersionList", "InstallPlanList":
+		list := struct {
+			Items []json.RawMessage `json:"items"`
+		}{}
+		if err := yaml.Unmarshal(yamlFile, &list); err != nil {
+			return fmt.Errorf("failed to unmarshal operator lifecycle list yaml: %w", err)
+		}
+		for _, item := range list.Items {
+			if err := l.storeOperatorLifecycleData(item); err != nil {
+				return err
+			}
+		}
+	default:
+		log.Log.Println("skipping unexpected operator lifecycle kind: ", typeMeta.Kind)
+	}
+	return nil
+}
+
 func (l *logsHandler) storeSubscriptionData(yamlFile []byte) error {
 	subscription := v1alpha1.Subscription{}
 
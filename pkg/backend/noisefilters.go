@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"strings"
+
+	"logsviewer/pkg/backend/db"
+)
+
+var noiseFilterSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"phrase":      map[string]interface{}{"type": "string", "description": "log lines whose msg contains the phrase are excluded"},
+		"description": map[string]interface{}{"type": "string"},
+		"enabled":     map[string]interface{}{"type": "boolean", "description": "defaults to true on creation"},
+	},
+}
+
+// noiseFilterRequest holds the fields sent by the client, missing fields are nil
+type noiseFilterRequest struct {
+	Phrase      *string `json:"phrase"`
+	Description *string `json:"description"`
+	Enabled     *bool   `json:"enabled"`
+}
+
+// apply copies the fields that were sent to filter
+func (r *noiseFilterRequest) apply(filter *db.NoiseFilter) error {
+	if r.Phrase != nil {
+		filter.Phrase = strings.TrimSpace(*r.Phrase)
+	}
+	if r.Description != nil {
+		filter.Description = *r.Description
+	}
+	if r.Enabled != nil {
+		filter.Enabled = *r.Enabled
+	}
+	if filter.Phrase == "" {
+		return badRequest("the phrase of a noise filter must not be empty")
+	}
+	return nil
+}
+
+func (c *app) createNoiseFilter(req *apiRequest) (interface{}, error) {
+	body := &noiseFilterRequest{}
+	if err := req.decode(body); err != nil {
+		return nil, err
+	}
+	filter := &db.NoiseFilter{Enabled: true}
+	if err := body.apply(filter); err != nil {
+		return nil, err
+	}
+	if err := c.storeDB.CreateNoiseFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (c *app) updateNoiseFilter(req *apiRequest) (interface{}, error) {
+	filter, err := c.storeDB.GetNoiseFilter(req.integer("id", 0))
+	if err != nil {
+		return nil, err
+	}
+	body := &noiseFilterRequest{}
+	if err := req.decode(body); err != nil {
+		return nil, err
+	}
+	if err := body.apply(filter); err != nil {
+		return nil, err
+	}
+	if err := c.storeDB.UpdateNoiseFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
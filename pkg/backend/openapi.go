@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"logsviewer/pkg/backend/auth"
+)
+
+// openAPIDocument describes every API route as an OpenAPI 3 document
+func openAPIDocument(routes []apiRoute) map[string]interface{} {
+	paths := map[string]interface{}{}
+	for _, route := range routes {
+		parameters := []interface{}{}
+		for _, p := range route.params {
+			schema := map[string]interface{}{"type": string(p.kind)}
+			if p.kind == durationParam || p.kind == timestampParam {
+				schema = map[string]interface{}{"type": "string", "format": string(p.kind)}
+			}
+			if len(p.enum) > 0 {
+				schema["enum"] = p.enum
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.name,
+				"in":          p.in,
+				"required":    p.required,
+				"description": p.description,
+				"schema":      schema,
+			})
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{"type": "object"},
+					},
+				},
+			},
+		}
+		codes := append([]int{}, route.errors...)
+		codes = append(codes, http.StatusUnauthorized)
+		if route.role > auth.Viewer {
+			codes = append(codes, http.StatusForbidden)
+		}
+		codes = append(codes, http.StatusInternalServerError)
+		for _, code := range codes {
+			responses[strconv.Itoa(code)] = map[string]interface{}{
+				"description": http.StatusText(code),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+					},
+				},
+			}
+		}
+
+		operation := map[string]interface{}{
+			"summary":     route.summary,
+			"operationId": operationID(route),
+			"parameters":  parameters,
+			"responses":   responses,
+		}
+		if route.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": route.body},
+				},
+			}
+		}
+		description := []string{"Requires the " + route.role.String() + " role."}
+		if len(route.aliases) > 0 {
+			aliases := []string{}
+			for _, alias := range route.aliases {
+				aliases = append(aliases, alias.path)
+			}
+			description = append(description, "Also served, with the legacy parameter names, at "+strings.Join(aliases, ", ")+".")
+		}
+		operation["description"] = strings.Join(description, " ")
+
+		item, exists := paths[route.path].(map[string]interface{})
+		if !exists {
+			item = map[string]interface{}{}
+			paths[route.path] = item
+		}
+		item[strings.ToLower(route.method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "LogsViewer API",
+			"version": "v1",
+		},
+		"paths": paths,
+		// authentication is optional, it depends on the configured auth mode
+		"security": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"basicAuth": []string{}},
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"basicAuth":  map[string]interface{}{"type": "http", "scheme": "basic"},
+			},
+			"schemas": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"error": map[string]interface{}{
+							"type":     "object",
+							"required": []string{"code", "reason", "message"},
+							"properties": map[string]interface{}{
+								"code":    map[string]interface{}{"type": "integer"},
+								"reason":  map[string]interface{}{"type": "string"},
+								"message": map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// operationID derives a stable id such as getVmisUidQuery from the route
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.method)
+	for _, part := range strings.Split(strings.TrimPrefix(route.path, apiPrefix), "/") {
+		part = strings.Trim(part, "{}")
+		if part == "" {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// OpenAPIDocument renders the OpenAPI document of the logsviewer API
+func OpenAPIDocument() ([]byte, error) {
+	return json.MarshalIndent(openAPIDocument((&app{}).apiRoutes()), "", "  ")
+}
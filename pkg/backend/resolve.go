@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"strings"
+
+	"logsviewer/pkg/backend/correlation"
+	"logsviewer/pkg/backend/db"
+)
+
+// resolvedObject is an object a UID or IP identifies, with its correlation query
+type resolvedObject struct {
+	db.ResolvedObject
+	Query interface{} `json:"query"`
+}
+
+func (c *app) resolve(req *apiRequest) (interface{}, error) {
+	value := strings.TrimSpace(req.str("value"))
+	if value == "" {
+		return nil, badRequest("the value to resolve must not be empty")
+	}
+	store := c.store(req)
+	objects, err := store.Resolve(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, notFound("no object has the UID or address %q", value)
+	}
+
+	resolved := make([]resolvedObject, 0, len(objects))
+	for _, object := range objects {
+		data, q, err := c.resolvedQuery(req, store, object)
+		if err != nil {
+			return nil, err
+		}
+		query, err := c.correlationResponse(req, q, data.Warnings)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, resolvedObject{ResolvedObject: object, Query: query})
+	}
+	return map[string]interface{}{
+		"data": resolved,
+		"meta": map[string]interface{}{"value": value, "count": len(resolved)},
+	}, nil
+}
+
+// resolvedQuery builds the correlation query matching the kind of object
+func (c *app) resolvedQuery(req *apiRequest, store db.Store, object db.ResolvedObject) (db.QueryResults, *correlation.Query, error) {
+	switch object.Kind {
+	case "pods":
+		data, err := store.GetPodQueryParams(object.UID)
+		return data, correlation.ForPod(data), err
+	case "vmis":
+		data, err := store.GetFullVMIHistoryQueryParams(object.UID)
+		return data, correlation.ForVMIHistory(data), err
+	case "nodes":
+		data, err := store.GetNodeQueryParams(object.Name)
+		if err == nil {
+			err = requireStart(req, data, "the node "+object.Name)
+		}
+		return data, correlation.ForNode(data), err
+	case "pvcs":
+		data, err := store.GetPVCQueryParams(object.UID)
+		return data, correlation.ForPVC(data), err
+	case "migrations":
+		data, err := store.GetMigrationQueryParams(object.UID)
+		return data, correlation.ForMigration(data), err
+	}
+	return db.QueryResults{}, nil, notFound("unknown object kind %q", object.Kind)
+}
@@ -0,0 +1,746 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"logsviewer/pkg/backend/auth"
+	"logsviewer/pkg/backend/correlation"
+	"logsviewer/pkg/backend/db"
+	"logsviewer/pkg/backend/log"
+	"logsviewer/pkg/backend/monitoring/metrics"
+)
+
+var (
+	objectKinds      = []string{"pods", "vms", "vmis", "nodes", "pvcs"}
+	healthStatuses   = []string{"healthy", "unhealthy", "warning"}
+	singularToPlural = map[string]string{"pod": "pods", "vm": "vms", "vmi": "vmis", "node": "nodes", "pvc": "pvcs"}
+)
+
+func uidParam() apiParam {
+	return pathParam("uid", "UID of the object")
+}
+
+func noiseFilterIDParam() apiParam {
+	return pathParam("id", "id of the noise filter").withKind(integerParam)
+}
+
+// clusterParam scopes a route to the objects of a cluster
+func clusterParam() apiParam {
+	return queryParam("cluster", "only the objects of this cluster, as listed by /clusters, every cluster when omitted")
+}
+
+func nodeParam() apiParam {
+	return queryParam("node", "name of the node the VMI runs on").withRequired()
+}
+
+// correlationParams adds the time window and column parameters of
+// correlation queries to params
+func correlationParams(params ...apiParam) []apiParam {
+	return append(params,
+		clusterParam(),
+		queryParam("pre", "time included before the object creation, such as 30s or 5m, defaults to 30s").withKind(durationParam),
+		queryParam("post", "time included after the end bound, defaults to 0s").withKind(durationParam),
+		queryParam("from", "explicit RFC 3339 start of the window, overrides the creation time and pre").withKind(timestampParam),
+		queryParam("to", "explicit RFC 3339 end of the window, overrides the end bound and post. "+
+			"The end bound defaults to the pod deletion or completion, the VMI Succeeded or Failed time, the migration end, "+
+			"or the must-gather timestamp").withKind(timestampParam),
+		queryParam("columns", "column set of the Kibana view and search, defaults to default").withEnum(correlation.ColumnSetNames()...),
+	)
+}
+
+func pagingParams() []apiParam {
+	return []apiParam{
+		queryParam("page", "page number, starting at 1").withKind(integerParam),
+		queryParam("per_page", "page size, all rows are returned when omitted").withKind(integerParam),
+	}
+}
+
+// listParams are the filters shared by the object lists of kind, with the
+// sort and cursor parameters
+func listParams(kind string, filters ...string) []apiParam {
+	params := []apiParam{
+		queryParam("sort", "column and direction to sort by, such as \"creationTime desc\", defaults to \"name asc\". "+
+			"The columns are "+strings.Join(db.SortableColumns(kind), ", ")),
+		queryParam("cursor", "the nextCursor of the previous page, pages are read after it instead of by page number"),
+		clusterParam(),
+	}
+	for _, filter := range filters {
+		switch filter {
+		case "name":
+			params = append(params, queryParam("name", "filter by name"))
+		case "namespace":
+			params = append(params, queryParam("namespace", "filter by namespace"))
+		case "uuid":
+			params = append(params, queryParam("uuid", "filter by UID"))
+		case "status":
+			params = append(params, queryParam("status", "filter by health").withEnum(healthStatuses...))
+		}
+	}
+	return append(params, pagingParams()...)
+}
+
+func pagination(req *apiRequest) (int, int, error) {
+	page := req.integer("page", 1)
+	perPage := req.integer("per_page", -1)
+	if page < 1 {
+		return 0, 0, badRequest("parameter \"page\" must be at least 1, got %d", page)
+	}
+	if perPage < 1 && perPage != -1 {
+		return 0, 0, badRequest("parameter \"per_page\" must be at least 1, got %d", perPage)
+	}
+	return page, perPage, nil
+}
+
+func queryDetails(kind string, req *apiRequest) (*db.GenericQueryDetails, error) {
+	details := &db.GenericQueryDetails{
+		Name:      req.str("name"),
+		Namespace: req.str("namespace"),
+		UUID:      req.str("uuid"),
+		Status:    req.str("status"),
+	}
+	if sort := req.str("sort"); sort != "" {
+		var err error
+		details.Sort, err = db.ParseListSort(kind, sort)
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+	}
+	if cursor := req.str("cursor"); cursor != "" {
+		if req.integer("page", 1) != 1 {
+			return nil, badRequest("a cursor can't be combined with a page number")
+		}
+		after, err := db.ParseListCursor(cursor, details.Sort)
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+		details.After = after
+	}
+	return details, nil
+}
+
+// listHandler serves a paginated, sorted and filtered object list of kind
+func (c *app) listHandler(kind string, list func(store db.Store, page int, perPage int, details *db.GenericQueryDetails) (map[string]interface{}, error)) func(req *apiRequest) (interface{}, error) {
+	return func(req *apiRequest) (interface{}, error) {
+		page, perPage, err := pagination(req)
+		if err != nil {
+			return nil, err
+		}
+		details, err := queryDetails(kind, req)
+		if err != nil {
+			return nil, err
+		}
+		return list(c.store(req), page, perPage, details)
+	}
+}
+
+// store is the object store scoped to the cluster of the request, if any
+func (c *app) store(req *apiRequest) db.Store {
+	return c.storeDB.InCluster(req.str("cluster"))
+}
+
+func (c *app) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{
+			method: http.MethodGet, path: apiPrefix + "/openapi.json",
+			summary: "OpenAPI document describing this API",
+			handle: func(req *apiRequest) (interface{}, error) {
+				return openAPIDocument(c.apiRoutes()), nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/stats",
+			summary: "Count objects of each kind by phase or status",
+			params:  []apiParam{clusterParam()},
+			aliases: []apiAlias{{path: "/getResourceStats"}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.store(req).GetResourceStats()
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/clusters",
+			summary: "List the clusters of the imported must-gathers, identified by their ClusterVersion ID or infrastructure name",
+			handle: func(req *apiRequest) (interface{}, error) {
+				clusters, err := c.storeDB.ListClusters()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"data": clusters}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/imports",
+			summary: "List the imported must-gathers",
+			params:  []apiParam{clusterParam()},
+			aliases: []apiAlias{{path: "/getImportedMustGathers"}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				imports, err := c.store(req).ListImportedMustGather()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"data": imports}, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: apiPrefix + "/imports",
+			summary: "Upload and import a must-gather archive, sent as the multipart form field \"file\"",
+			role:    auth.Uploader,
+			errors:  []int{http.StatusBadRequest, http.StatusConflict},
+			aliases: []apiAlias{{path: "/uploadLogs"}},
+			handle:  c.importMustGather,
+		},
+		{
+			method: http.MethodDelete, path: apiPrefix + "/imports/{id}",
+			summary: "Delete an import with the objects only found in it, its extracted files, its enrichment entries and the logs it ingested. " +
+				"The objects also found in another import are kept, with their state in the latest of them. " +
+				"The log lines of a pod without an enrichment entry aren't tagged with their import and are kept",
+			role:   auth.Uploader,
+			params: []apiParam{pathParam("id", "id of the import").withKind(integerParam)},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: c.deleteImport,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/imports/{from}/diff/{to}",
+			summary: "What changed between two imports of a cluster: the objects added and removed, the phase, node, restarts and fields of the others, " +
+				"and the insights findings that appeared or were resolved",
+			params: []apiParam{
+				pathParam("from", "id of the earlier import").withKind(integerParam),
+				pathParam("to", "id of the later import").withKind(integerParam),
+				queryParam("kind", "only compare this kind").withEnum(db.SnapshotKinds()...),
+				clusterParam(),
+			},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				kinds := []string{}
+				if kind := req.str("kind"); kind != "" {
+					kinds = append(kinds, kind)
+				}
+				diff, err := c.store(req).DiffImports(req.integer("from", 0), req.integer("to", 0), kinds)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"data": diff}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/pods",
+			summary: "List pods",
+			params:  listParams("pods", "name", "namespace", "uuid", "status"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/pods"}},
+			handle:  c.listHandler("pods", db.Store.GetPods),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/nodes",
+			summary: "List nodes",
+			params:  listParams("nodes", "name", "uuid", "status"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/nodes"}},
+			handle:  c.listHandler("nodes", db.Store.GetNodes),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vms",
+			summary: "List virtual machines",
+			params:  listParams("vms", "name", "namespace", "uuid"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/vms"}},
+			handle:  c.listHandler("vms", db.Store.GetVms),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis",
+			summary: "List virtual machine instances",
+			params:  listParams("vmis", "name", "namespace", "uuid", "status"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/vmis"}},
+			handle:  c.listHandler("vmis", db.Store.GetVmis),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/migrations",
+			summary: "List virtual machine instance migrations, name filters by VMI name",
+			params:  listParams("migrations", "name", "namespace", "uuid", "status"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/vmims"}},
+			handle:  c.listHandler("migrations", db.Store.GetVmiMigrations),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/pvcs",
+			summary: "List persistent volume claims, name accepts a comma separated list",
+			params:  listParams("pvcs", "name", "namespace", "uuid", "status"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/getPVCs"}},
+			handle:  c.listHandler("pvcs", db.Store.GetPVCs),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/subscriptions",
+			summary: "List OLM subscriptions",
+			params:  listParams("subscriptions", "name", "namespace", "uuid"),
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/getSubscriptions"}},
+			handle:  c.listHandler("subscriptions", db.Store.GetSubscriptions),
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/pods/{uid}/pvcs",
+			summary: "List the claims mounted by a pod",
+			params:  []apiParam{uidParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/getPodPVCs", rename: map[string]string{"uuid": "uid"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.store(req).GetPodPVCs(req.str("uid"))
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis/{uid}/pvcs",
+			summary: "List the claims mounted by the launcher pods of a VMI",
+			params:  []apiParam{uidParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest},
+			aliases: []apiAlias{{path: "/getVMIPVCs", rename: map[string]string{"uuid": "uid"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.store(req).GetVMIPVCs(req.str("uid"))
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/pods/{uid}/query",
+			summary: "Log correlation query of a pod",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{{path: "/getSinglePodQueryParams", rename: map[string]string{"uuid": "uid"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetPodQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForPod(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis/{uid}/query",
+			summary: "Log correlation query of a VMI on a node. The launcher and handler pods that can't be found are listed in warnings",
+			params:  correlationParams(uidParam(), nodeParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{{path: "/getVMIQueryParams", rename: map[string]string{"vmiUUID": "uid", "nodeName": "node"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetVMIQueryParams(req.str("uid"), req.str("node"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForVMI(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis/{uid}/details",
+			summary: "Pods and time range involved in running a VMI on a node",
+			params:  []apiParam{uidParam(), nodeParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{{path: "/getVMIDetails", rename: map[string]string{"uuid": "uid", "nodeName": "node"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.store(req).GetVMIQueryParams(req.str("uid"), req.str("node"))
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis/{uid}/history/query",
+			summary: "Log correlation query of a VMI across all the nodes it ran on",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{{path: "/getFullVMIHistoryQueryParams", rename: map[string]string{"vmiUUID": "uid"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetFullVMIHistoryQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForVMIHistory(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/vmis/{uid}/timeline",
+			summary: "Ordered timeline of a VMI: phase transitions, conditions, virt-launcher creation and termination, migrations and events, " +
+				"with the node of each step and the log query of its window, from the step until the next one",
+			params: []apiParam{
+				uidParam(),
+				clusterParam(),
+				queryParam("pre", "time included before each step, such as 30s or 5m, defaults to 30s").withKind(durationParam),
+				queryParam("post", "time included after the next step, defaults to 0s").withKind(durationParam),
+				queryParam("columns", "column set of the Kibana view, defaults to default").withEnum(correlation.ColumnSetNames()...),
+			},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: c.vmiTimeline,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/migrations/{uid}/query",
+			summary: "Log correlation query of a migration. The VMI, launcher and handler pods that can't be found are listed in warnings",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{{path: "/getMigrationQueryParams", rename: map[string]string{"uuid": "uid"}}},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetMigrationQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForMigration(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/nodes/{name}/query",
+			summary: "Log correlation query of a node, its virt-handler, the virt-launchers scheduled on it and the controllers. " +
+				"The window starts at the last transition of the node Ready condition, or the creation of its first virt-handler or virt-launcher. " +
+				"from is required when neither is known, and cluster when nodes of several clusters share the name",
+			params: correlationParams(pathParam("name", "name of the node")),
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetNodeQueryParams(req.str("name"))
+				if err != nil {
+					return nil, err
+				}
+				if err := requireStart(req, data, "the node"); err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForNode(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/pvcs/{uid}/query",
+			summary: "Log correlation query of a claim, its DataVolume, the CDI and CSI pods provisioning it and the virt-launchers mounting it",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetPVCQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForPVC(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/subscriptions/{uid}/query",
+			summary: "Log correlation query of the installs and upgrades of a subscription, bounded by its InstallPlans and CSVs, with the operator pods involved",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetSubscriptionQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.operatorCorrelationResponse(req, data)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/csvs/{uid}/query",
+			summary: "Log correlation query of the install or upgrade of a CSV, bounded by its InstallPlans, with the operator pods involved",
+			params:  correlationParams(uidParam()),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetCSVQueryParams(req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return c.operatorCorrelationResponse(req, data)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/namespaces/{name}/summary",
+			summary: "VMs, VMIs, pods, PVCs, migrations and events of a namespace counted by status, with the unhealthy ones and the latest warnings",
+			params:  []apiParam{pathParam("name", "name of the namespace"), clusterParam()},
+			errors:  []int{http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.store(req).GetNamespaceSummary(req.str("name"))
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/namespaces/{name}/query",
+			summary: "Log correlation query of every pod and VMI of a namespace, the virt-handlers of the nodes its pods ran on and the controllers",
+			params:  correlationParams(pathParam("name", "name of the namespace")),
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				data, err := c.store(req).GetNamespaceQueryParams(req.str("name"))
+				if err != nil {
+					return nil, err
+				}
+				return c.correlationResponse(req, correlation.ForNamespace(data), data.Warnings)
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/search",
+			summary: "Search every kind for a name, UID, IP or MAC address, or node name. " +
+				"Results are ranked, exact UIDs first and partial names last, and link to the object",
+			params: []apiParam{
+				queryParam("q", "the term to look for").withRequired(),
+				queryParam("kind", "only search this kind").withEnum(db.SearchKinds()...),
+				queryParam("limit", "maximum number of results, defaults to 50").withKind(integerParam),
+				clusterParam(),
+			},
+			errors: []int{http.StatusBadRequest},
+			handle: c.search,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/search/content",
+			summary: "List the objects of a kind by their stored content: the objects containing a text, " +
+				"or having a field at a JSONPath such as {.spec.domain.cpu.dedicatedCpuPlacement} equal to a value. " +
+				"Rows are those of the object list, with the values found at the path under matches",
+			params: append([]apiParam{
+				queryParam("kind", "kind of the objects").withEnum(db.ListKinds()...).withRequired(),
+				queryParam("text", "text the content must contain"),
+				queryParam("path", "JSONPath of a field, [*] selects every element of an array, such as {.spec.volumes[*].configMap.name}"),
+				queryParam("value", "value the field must be equal to, any value matches when omitted"),
+				clusterParam(),
+			}, pagingParams()...),
+			errors: []int{http.StatusBadRequest},
+			handle: c.searchContent,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/resolve",
+			summary: "Resolve a UID or IP address from a log line to the pods, VMIs, nodes, PVCs or migrations it identifies, " +
+				"with their owners and correlation queries. IPs are matched against pod IPs, VMI interface IPs and node addresses",
+			params: correlationParams(queryParam("value", "the UID or IP address to resolve").withRequired()),
+			errors: []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: c.resolve,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/noise-filters",
+			summary: "List the noise filters, the enabled ones are excluded from every correlation query",
+			handle: func(req *apiRequest) (interface{}, error) {
+				filters, err := c.storeDB.ListNoiseFilters()
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"data": filters}, nil
+			},
+		},
+		{
+			method: http.MethodPost, path: apiPrefix + "/noise-filters",
+			summary: "Create a noise filter",
+			role:    auth.Uploader,
+			errors:  []int{http.StatusBadRequest},
+			body:    noiseFilterSchema,
+			handle:  c.createNoiseFilter,
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/noise-filters/{id}",
+			summary: "Get a noise filter",
+			params:  []apiParam{noiseFilterIDParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.storeDB.GetNoiseFilter(req.integer("id", 0))
+			},
+		},
+		{
+			method: http.MethodPatch, path: apiPrefix + "/noise-filters/{id}",
+			summary: "Update the fields sent of a noise filter, {\"enabled\": false} disables it",
+			role:    auth.Uploader,
+			params:  []apiParam{noiseFilterIDParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			body:    noiseFilterSchema,
+			handle:  c.updateNoiseFilter,
+		},
+		{
+			method: http.MethodDelete, path: apiPrefix + "/noise-filters/{id}",
+			summary: "Delete a noise filter",
+			role:    auth.Uploader,
+			params:  []apiParam{noiseFilterIDParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				id := req.integer("id", 0)
+				if err := c.storeDB.DeleteNoiseFilter(id); err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{"deleted": id}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/{kind}/{uid}/history",
+			summary: "Every state of an object across the imported must-gathers, by gather time. The lists show the latest one",
+			params:  []apiParam{pathParam("kind", "kind of the object").withEnum(db.SnapshotKinds()...), uidParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				snapshots, err := c.store(req).GetObjectHistory(req.str("kind"), req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{
+					"data": snapshots,
+					"meta": map[string]interface{}{"kind": req.str("kind"), "uid": req.str("uid"), "count": len(snapshots)},
+				}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/{kind}/{uid}/yaml",
+			summary: "YAML manifest of an object",
+			params:  []apiParam{pathParam("kind", "kind of the object").withEnum(objectKinds...), uidParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			aliases: []apiAlias{
+				{
+					path:   "/getObjYaml",
+					rename: map[string]string{"object": "kind", "uuid": "uid"},
+					rewrite: func(values map[string]string) {
+						if plural, exists := singularToPlural[values["kind"]]; exists {
+							values["kind"] = plural
+						}
+					},
+				},
+				{
+					path:   "/getPodYaml",
+					rename: map[string]string{"uuid": "uid"},
+					rewrite: func(values map[string]string) {
+						values["kind"] = "pods"
+					},
+				},
+			},
+			handle: func(req *apiRequest) (interface{}, error) {
+				obj, err := c.getObject(c.store(req), req.str("kind"), req.str("uid"))
+				if err != nil {
+					return nil, err
+				}
+				outYaml, err := yaml.Marshal(obj)
+				if err != nil {
+					return nil, fmt.Errorf("failed marshal obj to yaml: %w", err)
+				}
+				return map[string]string{"yaml": string(outYaml)}, nil
+			},
+		},
+		{
+			method: http.MethodGet, path: apiPrefix + "/{kind}/{uid}",
+			summary: "Stored manifest of an object",
+			params:  []apiParam{pathParam("kind", "kind of the object").withEnum(objectKinds...), uidParam(), clusterParam()},
+			errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+			handle: func(req *apiRequest) (interface{}, error) {
+				return c.getObject(c.store(req), req.str("kind"), req.str("uid"))
+			},
+		},
+	}
+}
+
+func (c *app) getObject(store db.Store, kind string, uid string) (interface{}, error) {
+	switch kind {
+	case "pods":
+		return store.GetPodObject(uid)
+	case "vms":
+		return store.GetVMObject(uid)
+	case "vmis":
+		return store.GetVMIObject(uid)
+	case "nodes":
+		return store.GetNodeObject(uid)
+	case "pvcs":
+		return store.GetPVCObject(uid)
+	}
+	return nil, notFound("unknown object kind %q", kind)
+}
+
+func (c *app) importMustGather(req *apiRequest) (interface{}, error) {
+	log.Log.Println("File Upload Endpoint Hit")
+	c.dataDirLock.Lock()
+	defer c.dataDirLock.Unlock()
+
+	// Parse our multipart form, 10 << 20 specifies a maximum
+	// upload of 10 MB files.
+	req.ParseMultipartForm(10 << 20)
+	// FormFile returns the first file for the given key `myFile`
+	// it also returns the FileHeader so we can get the Filename,
+	// the Header and the size of the file
+	file, handler, err := req.FormFile("file")
+	if err != nil {
+		return nil, badRequest("failed to retrieve the uploaded file: %v", err)
+	}
+	defer file.Close()
+	log.Log.Println("Uploaded File: ", handler.Filename, " size: ", handler.Size)
+
+	mime := handler.Header.Get("Content-Type")
+	if mime != "application/gzip" && mime != "application/x-gzip" && mime != "application/x-compressed-tar" {
+		return nil, badRequest("unsupported content type %q, a gzipped tar archive is expected", mime)
+	}
+
+	_, exists, err := c.storeDB.GetImportedMustGather(handler.Filename)
+	if err != nil {
+		log.Log.Println("failed to fetch imported must gather", err)
+		return nil, err
+	}
+	if exists {
+		log.Log.Println("must gather already exists")
+		return nil, conflict("must gather %s was already imported", handler.Filename)
+	}
+
+	dataDir := c.config.DataDir
+	d, err := os.Open(dataDir)
+	if err == nil {
+		defer d.Close()
+		names, err := d.Readdirnames(-1)
+		if err == nil {
+			for _, name := range names {
+				_ = os.RemoveAll(filepath.Join(dataDir, name))
+			}
+		}
+	}
+
+	err = os.MkdirAll(dataDir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationFilePath := filepath.Join(dataDir, filepath.Base(handler.Filename))
+	dst, err := os.Create(destinationFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	// Copy the uploaded file to the filesystem
+	// at the specified destination
+	_, err = io.Copy(dst, file)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Log.Println("Successfully Uploaded File: ", handler.Filename)
+	metrics.NewMustGatherUploaded()
+
+	if err := handleTarGz(destinationFilePath, dataDir); err != nil {
+		return nil, err
+	}
+
+	var insightsData string
+	if c.IsInsightsEnabled() {
+		log.Log.Println("executing insights", dataDir)
+		insightsDataRaw, insightsErr := c.insightsInstance.Exec(dataDir)
+		if insightsErr != nil {
+			log.Log.Println("failed to execute insights", insightsErr)
+		}
+		insightsData = string(insightsDataRaw)
+	} else {
+		log.Log.Println("insights is disabled")
+	}
+
+	logsHandler := NewLogsHandler(c.storeDB, c.config)
+	defer close(logsHandler.stopCh)
+	if err := logsHandler.processImportedMustGather(handler.Filename, insightsData); err != nil {
+		log.Log.Println("failed to store imported must gather", err)
+		return nil, err
+	}
+
+	var errList []error
+	for _, process := range []func() error{
+		logsHandler.processPodYAMLs,
+		logsHandler.processVirtualMachineInstanceMigrationsYAMLs,
+		logsHandler.processNodeYAMLs,
+		logsHandler.processVirtualMachineYAMLs,
+		logsHandler.processVirtualMachineInstanceYAMLs,
+		logsHandler.processPersistentVolumeClaimYAMLs,
+		logsHandler.processSubscriptionsYAMLs,
+		logsHandler.processOperatorLifecycleYAMLs,
+		logsHandler.processEventYAMLs,
+	} {
+		if err := process(); err != nil {
+			errList = append(errList, err)
+		}
+	}
+	if len(errList) > 0 {
+		return nil, fmt.Errorf("failed to process YAMLs: %v", errList)
+	}
+
+	return map[string]interface{}{
+		"success":     true,
+		"description": "Successfully Uploaded File",
+	}, nil
+}
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"net/url"
+	"strings"
+
+	"logsviewer/pkg/backend/db"
+)
+
+// defaultSearchLimit caps the results of a search when no limit is given
+const defaultSearchLimit = 50
+
+// searchLinks are the API paths an object found by a search leads to
+var searchLinks = map[string]string{
+	"pods":          "/pods/{uid}",
+	"vms":           "/vms/{uid}",
+	"vmis":          "/vmis/{uid}",
+	"nodes":         "/nodes/{uid}",
+	"pvcs":          "/pvcs/{uid}",
+	"migrations":    "/migrations/{uid}/query",
+	"subscriptions": "/subscriptions/{uid}/query",
+	"csvs":          "/csvs/{uid}/query",
+}
+
+func (c *app) search(req *apiRequest) (interface{}, error) {
+	term := strings.TrimSpace(req.str("q"))
+	if term == "" {
+		return nil, badRequest("the search term must not be empty")
+	}
+	kinds := []string{}
+	if kind := req.str("kind"); kind != "" {
+		kinds = append(kinds, kind)
+	}
+	limit := req.integer("limit", defaultSearchLimit)
+	if limit < 1 {
+		return nil, badRequest("the limit must be positive")
+	}
+
+	results, err := c.store(req).Search(term, kinds, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if link, exists := searchLinks[results[i].Kind]; exists && results[i].UID != "" {
+			results[i].Link = apiPrefix + strings.Replace(link, "{uid}", url.PathEscape(results[i].UID), 1)
+		}
+	}
+	return map[string]interface{}{
+		"data": results,
+		"meta": map[string]interface{}{"query": term, "count": len(results), "limit": limit},
+	}, nil
+}
+
+func (c *app) searchContent(req *apiRequest) (interface{}, error) {
+	page, perPage, err := pagination(req)
+	if err != nil {
+		return nil, err
+	}
+	search := db.ContentSearch{Text: req.str("text"), Value: req.str("value")}
+	if path := strings.TrimSpace(req.str("path")); path != "" {
+		search.Path, err = db.ParseContentPath(path)
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+	} else if search.Value != "" {
+		return nil, badRequest("a value can only be matched at a path")
+	}
+	if search.Text == "" && search.Path == nil {
+		return nil, badRequest("either a text or a path is required")
+	}
+	return c.store(req).SearchContent(req.str("kind"), search, page, perPage)
+}
@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"net/url"
+	"time"
+
+	"logsviewer/pkg/backend/correlation"
+	"logsviewer/pkg/backend/db"
+)
+
+// minTimelineWindow is the shortest window of a timeline entry, steps
+// following each other closely still get a useful log query
+const minTimelineWindow = time.Minute
+
+// timelineEntry is a step of a VMI timeline with the log query of its
+// window, from the step until the next one
+type timelineEntry struct {
+	db.TimelineEntry
+	From time.Time  `json:"from"`
+	To   *time.Time `json:"to,omitempty"`
+	// QueryLink is the API path of the VMI history query over the window
+	QueryLink string `json:"queryLink"`
+	// DSLQuery holds the _g and _a Kibana Discover parameters, like the correlation queries
+	DSLQuery string `json:"dslQuery"`
+	KQL      string `json:"kql"`
+}
+
+func (c *app) vmiTimeline(req *apiRequest) (interface{}, error) {
+	uid := req.str("uid")
+	entries, err := c.store(req).GetVMITimeline(uid)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.store(req).GetFullVMIHistoryQueryParams(uid)
+	if err != nil {
+		return nil, err
+	}
+	end := history.EndTimestamp
+	if end.IsZero() {
+		if end, err = c.store(req).LatestGatherTime(); err != nil {
+			return nil, err
+		}
+	}
+
+	pre, post := req.duration("pre", correlation.DefaultPadding), req.duration("post", 0)
+	timeline := make([]timelineEntry, 0, len(entries))
+	queries := make([]*correlation.Query, 0, len(entries))
+	for i, entry := range entries {
+		to := end
+		for _, next := range entries[i+1:] {
+			if next.Time.After(entry.Time) {
+				to = next.Time
+				break
+			}
+		}
+		if !to.IsZero() && to.Sub(entry.Time) < minTimelineWindow {
+			to = entry.Time.Add(minTimelineWindow)
+		}
+		q := correlation.ForVMIHistory(history).Between(entry.Time, to).Pad(pre, post)
+		if columns := req.str("columns"); columns != "" {
+			q.WithColumns(correlation.ColumnSets[columns]...)
+		}
+		inCluster(req, q)
+		queries = append(queries, q)
+		timeline = append(timeline, timelineEntry{TimelineEntry: entry, From: q.From})
+	}
+	if err := c.excludeNoise(queries...); err != nil {
+		return nil, err
+	}
+
+	for i, q := range queries {
+		values := url.Values{"from": {q.From.Format(time.RFC3339)}}
+		if !q.To.IsZero() {
+			to := q.To
+			timeline[i].To = &to
+			values.Set("to", to.Format(time.RFC3339))
+		}
+		if columns := req.str("columns"); columns != "" {
+			values.Set("columns", columns)
+		}
+		if cluster := req.str("cluster"); cluster != "" {
+			values.Set("cluster", cluster)
+		}
+		timeline[i].QueryLink = apiPrefix + "/vmis/" + url.PathEscape(uid) + "/history/query?" + values.Encode()
+		timeline[i].DSLQuery = q.KibanaURL()
+		timeline[i].KQL = q.KQL()
+	}
+
+	return map[string]interface{}{
+		"data":     timeline,
+		"warnings": append([]string{}, history.Warnings...),
+	}, nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+
+	"logsviewer/pkg/backend"
+)
+
+func main() {
+	document, err := backend.OpenAPIDocument()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(document))
+}